@@ -21,24 +21,205 @@
 package args
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"time"
 )
 
+// Command is the command given on the command line, it is empty when the
+// discovery runs as a pluggable-discovery
+var Command string
+
+// Format is the output format of the "list" command
+var Format = "table"
+
+// Color selects when the output of the "watch" command is colored: auto, always or never
+var Color = "auto"
+
+// Columns are the port fields and properties printed by the "watch" command
+var Columns = []string{"address", "protocol", "vid", "pid", "serialNumber", "boardName"}
+
+// Match is the expression matching the ports the "wait-for" command waits for
+var Match string
+
+// Gone makes the "wait-for" command wait for the matching ports to disappear
+var Gone bool
+
+// Timeout is the maximum time the "wait-for" command waits, zero means no limit
+var Timeout time.Duration
+
 // ShowVersion FIXMEDOC
 var ShowVersion bool
 
-// Parse arguments passed by the user
+// RetryAttempts is the number of times a failed port enumeration is retried
+var RetryAttempts = 5
+
+// RetryDelay is the delay before the first retry of a failed port enumeration,
+// it is doubled on each subsequent retry
+var RetryDelay = 50 * time.Millisecond
+
+// RetryMaxDelay is the upper bound of the delay between two retries
+var RetryMaxDelay = time.Second
+
+// StableAfter is the time a new port must stay connected before being reported
+var StableAfter time.Duration
+
+// RemoveGrace is the time a port may stay disconnected without being reported as removed
+var RemoveGrace time.Duration
+
+// CorrelationWindow is the time within which a board re-enumerated with a different port is recognized
+var CorrelationWindow = 5 * time.Second
+
+// HardwareIDMethods is the ordered list of methods used to compute the hardware ID of USB ports
+var HardwareIDMethods = []string{"serial", "topology", "descriptor"}
+
+// BoardsDirs are the directories searched for the boards.txt files used to identify the connected boards
+var BoardsDirs []string
+
+// USBIDsPath is the path of the usb.ids database, if empty the database is searched in the default system paths
+var USBIDsPath string
+
+// NoUSBIDs disables the lookup of USB vendor and product names
+var NoUSBIDs bool
+
+// ReportUnbound enables the reporting of known USB devices without a serial port
+var ReportUnbound bool
+
+// UF2Drives enables the discovery of UF2 bootloader drives
+var UF2Drives bool
+
+// DFUDevices enables the discovery of USB devices in DFU mode
+var DFUDevices bool
+
+// Filter is the filter expression applied to the reported ports
+var Filter string
+
+// RulesPath is the path of the file with the include/exclude rules applied to the ports
+var RulesPath string
+
+// Listen is the address the pluggable-discovery protocol is served on, instead of stdin/stdout
+var Listen string
+
+// HTTP is the address of the HTTP server of the port events
+var HTTP string
+
+// HTTPAllowOrigins are the origins of the web pages allowed to use the HTTP server
+var HTTPAllowOrigins []string
+
+// HTTPAllowHosts are the host names accepted by the HTTP server, besides the IP addresses and localhost
+var HTTPAllowHosts []string
+
+// Diagnostics enables the output of diagnostic messages on stderr
+var Diagnostics bool
+
+// Parse parses the command line arguments, on error the program is terminated
 func Parse() {
+	flags := flag.NewFlagSet("serial-discovery", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.BoolVar(&ShowVersion, "v", false, "print the version and exit")
+	flags.BoolVar(&ShowVersion, "version", false, "print the version and exit")
+	flags.IntVar(&RetryAttempts, "retry-attempts", RetryAttempts, "number of retries of a failed port enumeration")
+	flags.DurationVar(&RetryDelay, "retry-delay", RetryDelay, "delay before the first retry, doubled on each retry")
+	flags.DurationVar(&RetryMaxDelay, "retry-max-delay", RetryMaxDelay, "maximum delay between two retries")
+	flags.DurationVar(&StableAfter, "stable-after", 0, "time a new port must stay connected before being reported")
+	flags.DurationVar(&RemoveGrace, "remove-grace", 0, "time a port may stay disconnected without being reported as removed")
+	flags.DurationVar(&CorrelationWindow, "correlation-window", CorrelationWindow, "time within which a board re-enumerated with a different port is recognized")
+	flags.Func("hardware-id", "comma separated `methods` used to compute the hardware ID: serial, topology, descriptor", func(value string) error {
+		HardwareIDMethods = strings.Split(value, ",")
+		return nil
+	})
+	flags.Func("boards-dir", "`directory` searched for boards.txt files, may be repeated", func(value string) error {
+		BoardsDirs = append(BoardsDirs, value)
+		return nil
+	})
+	flags.StringVar(&USBIDsPath, "usb-ids", "", "`path` of the usb.ids database")
+	flags.BoolVar(&NoUSBIDs, "no-usb-ids", false, "disable the lookup of USB vendor and product names")
+	flags.BoolVar(&ReportUnbound, "report-unbound", false, "report known USB devices without a serial port")
+	flags.BoolVar(&UF2Drives, "uf2", false, "discover UF2 bootloader drives")
+	flags.BoolVar(&DFUDevices, "dfu", false, "discover USB devices in DFU mode")
+	flags.StringVar(&Filter, "filter", "", "`expression` selecting the reported ports")
+	flags.StringVar(&RulesPath, "rules", "", "`path` of the file with the include/exclude rules")
+	flags.StringVar(&Listen, "listen", "", "`address` the protocol is served on instead of stdin/stdout")
+	flags.StringVar(&HTTP, "http", "", "`address` of the HTTP server of the port events")
+	flags.Func("http-allow-origin", "`origin` of a web page allowed to use the HTTP server, may be repeated", func(value string) error {
+		HTTPAllowOrigins = append(HTTPAllowOrigins, value)
+		return nil
+	})
+	flags.Func("http-allow-host", "`host` name accepted by the HTTP server, may be repeated", func(value string) error {
+		HTTPAllowHosts = append(HTTPAllowHosts, value)
+		return nil
+	})
+	flags.BoolVar(&Diagnostics, "diagnostics", false, "print diagnostic messages on stderr")
+
+	cmdLine := []string{}
 	for _, arg := range os.Args[1:] {
-		if arg == "" {
-			continue
+		if arg != "" {
+			cmdLine = append(cmdLine, arg)
 		}
-		if arg == "-v" || arg == "--version" {
-			ShowVersion = true
-			continue
+	}
+	if len(cmdLine) > 0 && !strings.HasPrefix(cmdLine[0], "-") {
+		Command = cmdLine[0]
+		cmdLine = cmdLine[1:]
+		switch Command {
+		case "list":
+			flags.StringVar(&Format, "format", Format, "output `format`: table, json, ndjson or a Go template")
+		case "wait-for":
+			Format = "{{.Address}}"
+			flags.StringVar(&Format, "format", Format, "output `format` of the matching ports, as for the list command")
+			flags.StringVar(&Match, "match", "", "`expression` matching the ports to wait for")
+			flags.BoolVar(&Gone, "gone", false, "wait for the matching ports to disappear")
+			flags.DurationVar(&Timeout, "timeout", 0, "maximum time to wait, zero means no limit")
+		case "watch":
+			flags.StringVar(&Color, "color", Color, "color the output: auto, always or never")
+			flags.Func("columns", "comma separated port `fields` and properties to print", func(value string) error {
+				Columns = strings.Split(value, ",")
+				return nil
+			})
+		default:
+			fmt.Fprintf(os.Stderr, "invalid argument: unknown command %s\n", Command)
+			os.Exit(1)
 		}
-		fmt.Fprintf(os.Stderr, "invalid argument: %s\n", arg)
+	}
+	if err := flags.Parse(cmdLine); errors.Is(err, flag.ErrHelp) {
+		printUsage(flags)
+		os.Exit(0)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid argument: %s\n", err)
+		os.Exit(1)
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "invalid argument: %s\n", flags.Arg(0))
+		os.Exit(1)
+	}
+	if StableAfter < 0 || RemoveGrace < 0 || CorrelationWindow < 0 || Timeout < 0 {
+		fmt.Fprintf(os.Stderr, "invalid argument: durations must not be negative\n")
+		os.Exit(1)
+	}
+	if RetryAttempts < 0 {
+		fmt.Fprintf(os.Stderr, "invalid argument: --retry-attempts must not be negative\n")
 		os.Exit(1)
 	}
+	if RetryDelay <= 0 || RetryMaxDelay <= 0 {
+		fmt.Fprintf(os.Stderr, "invalid argument: --retry-delay and --retry-max-delay must be positive\n")
+		os.Exit(1)
+	}
+}
+
+// printUsage prints the help of the command line on stdout
+func printUsage(flags *flag.FlagSet) {
+	if Command == "" {
+		fmt.Println("Usage: serial-discovery [list|wait-for|watch] [flags]")
+		fmt.Println()
+		fmt.Println("Without a command the pluggable-discovery protocol is served on stdin/stdout.")
+	} else {
+		fmt.Printf("Usage: serial-discovery %s [flags]\n", Command)
+	}
+	fmt.Println()
+	fmt.Println("Flags:")
+	flags.SetOutput(os.Stdout)
+	flags.PrintDefaults()
 }
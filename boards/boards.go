@@ -0,0 +1,152 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+// Package boards identifies the boards connected to the USB ports by
+// looking at the upload_port VID/PID definitions found in the boards.txt
+// files of the installed Arduino platforms.
+package boards
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/arduino/go-properties-orderedmap"
+)
+
+// Board is a board definition of an Arduino platform
+type Board struct {
+	FQBN string
+	Name string
+}
+
+// Index allows to find the boards matching a USB VID/PID
+type Index struct {
+	boards  map[string][]*Board
+	vendors map[string]bool
+}
+
+// boardsTxtPatterns are the locations of the boards.txt files relative to
+// the searched directories, that may be:
+// - the Arduino data directory, or its "packages" folder
+// - the sketchbook, or its "hardware" folder
+var boardsTxtPatterns = []string{
+	"packages/*/hardware/*/*/boards.txt",
+	"*/hardware/*/*/boards.txt",
+	"hardware/*/*/boards.txt",
+	"*/*/boards.txt",
+}
+
+// Load searches the boards.txt files inside the given directories, for
+// example the "packages" folder of the Arduino data directory or the
+// "hardware" folder of the sketchbook, and builds an Index from them.
+// The files that cannot be loaded are skipped: the Index is always
+// returned, along with an error listing the skipped files.
+func Load(dirs []string) (*Index, error) {
+	idx := &Index{boards: map[string][]*Board{}, vendors: map[string]bool{}}
+	var errs []error
+	for _, dir := range dirs {
+		for _, pattern := range boardsTxtPatterns {
+			paths, _ := filepath.Glob(filepath.Join(dir, pattern))
+			for _, path := range paths {
+				if err := idx.loadBoardsTxt(path); err != nil {
+					errs = append(errs, fmt.Errorf("skipping %s: %w", path, err))
+				}
+			}
+		}
+	}
+	return idx, errors.Join(errs...)
+}
+
+func (idx *Index) loadBoardsTxt(path string) error {
+	boardsTxt, err := properties.Load(path)
+	if err != nil {
+		return err
+	}
+	packager, arch := platformID(filepath.Dir(path))
+	for _, boardID := range boardsTxt.FirstLevelKeys() {
+		if boardID == "menu" {
+			continue
+		}
+		boardProps := boardsTxt.SubTree(boardID)
+		board := &Board{
+			FQBN: packager + ":" + arch + ":" + boardID,
+			Name: boardProps.Get("name"),
+		}
+		for _, uploadPort := range boardProps.ExtractSubIndexSets("upload_port") {
+			idx.add(uploadPort.Get("vid"), uploadPort.Get("pid"), board)
+		}
+		// Legacy format: board.vid.N / board.pid.N
+		for i := 0; ; i++ {
+			vid, ok := boardProps.GetOk(fmt.Sprintf("vid.%d", i))
+			if !ok {
+				break
+			}
+			idx.add(vid, boardProps.Get(fmt.Sprintf("pid.%d", i)), board)
+		}
+	}
+	return nil
+}
+
+func (idx *Index) add(vid, pid string, board *Board) {
+	if vid == "" || pid == "" {
+		return
+	}
+	key := usbID(vid, pid)
+	idx.vendors[normalizeID(vid)] = true
+	for _, b := range idx.boards[key] {
+		if b.FQBN == board.FQBN {
+			return
+		}
+	}
+	idx.boards[key] = append(idx.boards[key], board)
+}
+
+// Match returns the boards matching the given USB VID and PID
+func (idx *Index) Match(vid, pid string) []*Board {
+	return idx.boards[usbID(vid, pid)]
+}
+
+// HasVendor returns true if a board with the given USB VID is defined
+func (idx *Index) HasVendor(vid string) bool {
+	return idx.vendors[normalizeID(vid)]
+}
+
+func usbID(vid, pid string) string {
+	return normalizeID(vid) + ":" + normalizeID(pid)
+}
+
+func normalizeID(id string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(id)), "0x")
+}
+
+// platformID returns the packager and the architecture of the platform
+// installed in the given directory, that may be either:
+// - PACKAGES/PACKAGER/hardware/ARCH/VERSION (installed with the boards manager)
+// - SKETCHBOOK/hardware/PACKAGER/ARCH (manually installed)
+func platformID(dir string) (packager, arch string) {
+	parts := strings.Split(filepath.ToSlash(filepath.Clean(dir)), "/")
+	n := len(parts)
+	if n >= 5 && parts[n-3] == "hardware" && parts[n-5] == "packages" {
+		return parts[n-4], parts[n-2]
+	}
+	if n >= 2 {
+		return parts[n-2], parts[n-1]
+	}
+	return "", parts[n-1]
+}
@@ -0,0 +1,175 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+// Package filter implements the port filters used to restrict the ports
+// reported to a client.
+//
+// A filter is a list of space separated terms in the form key=pattern, a
+// port matches the filter if it matches all the terms. The key is one of
+// "address", "label", "protocol", "hardwareId", "serial" (an alias for the
+// "serialNumber" property) or the name of any port property, for example
+// "vid", "pid" or "boardName". The pattern is a glob (see path.Match) and
+// may list more alternatives separated by commas, for example:
+//
+//	vid=0x2341,0x2a03 address=/dev/ttyACM*
+//
+// The values of "vid" and "pid" are compared ignoring case and the "0x"
+// prefix. Patterns containing spaces may be enclosed in double quotes.
+package filter
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+)
+
+// Filter is a parsed port filter
+type Filter struct {
+	expr  string
+	terms []*term
+}
+
+type term struct {
+	key      string
+	patterns []string
+}
+
+// Parse parses a filter expression, an empty expression returns a nil
+// Filter that matches all the ports.
+func Parse(expr string) (*Filter, error) {
+	tokens, err := split(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	f := &Filter{expr: strings.TrimSpace(expr)}
+	for _, token := range tokens {
+		key, value, ok := strings.Cut(token, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid filter term '%s', expected key=pattern", token)
+		}
+		if key == "serial" {
+			key = "serialNumber"
+		}
+		t := &term{key: key}
+		for _, pattern := range strings.Split(value, ",") {
+			pattern = Normalize(key, pattern)
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid pattern '%s' in filter term '%s'", pattern, token)
+			}
+			t.patterns = append(t.patterns, pattern)
+		}
+		f.terms = append(f.terms, t)
+	}
+	return f, nil
+}
+
+// String returns the filter expression
+func (f *Filter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.expr
+}
+
+// Match returns true if the port matches the filter, a nil Filter matches
+// all the ports
+func (f *Filter) Match(port *discovery.Port) bool {
+	if f == nil {
+		return true
+	}
+	for _, t := range f.terms {
+		if !t.match(port) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *term) match(port *discovery.Port) bool {
+	var value string
+	switch t.key {
+	case "address":
+		value = port.Address
+	case "label":
+		value = port.AddressLabel
+	case "protocol":
+		value = port.Protocol
+	case "hardwareId":
+		value = port.HardwareID
+	default:
+		if port.Properties == nil {
+			return false
+		}
+		v, ok := port.Properties.GetOk(t.key)
+		if !ok {
+			return false
+		}
+		value = Normalize(t.key, v)
+	}
+	for _, pattern := range t.patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize returns the canonical form of the values (and patterns) of the
+// given key: VIDs and PIDs are compared in lowercase, without "0x" prefix
+func Normalize(key, value string) string {
+	if key == "vid" || key == "pid" {
+		value = strings.ToLower(value)
+		return strings.TrimPrefix(value, "0x")
+	}
+	return value
+}
+
+// split splits the expression in space separated tokens, the spaces
+// enclosed in double quotes are kept and the quotes removed
+func split(expr string) ([]string, error) {
+	var tokens []string
+	var token strings.Builder
+	inToken, quoted := false, false
+	for _, c := range expr {
+		switch {
+		case c == '"':
+			quoted = !quoted
+			inToken = true
+		case !quoted && (c == ' ' || c == '\t'):
+			if inToken {
+				tokens = append(tokens, token.String())
+				token.Reset()
+				inToken = false
+			}
+		default:
+			token.WriteRune(c)
+			inToken = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote in filter '%s'", expr)
+	}
+	if inToken {
+		tokens = append(tokens, token.String())
+	}
+	return tokens, nil
+}
@@ -0,0 +1,93 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package filter
+
+import (
+	gosync "sync"
+
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+)
+
+// Stream applies a filter to a stream of port events: only the events of
+// the ports matching the filter are forwarded. The filter may be changed
+// while the stream is running, in that case the ports that start matching
+// are reported as added and the ones that stop matching as removed.
+type Stream struct {
+	lock    gosync.Mutex
+	eventCB discovery.EventCallback
+	filter  *Filter
+	ports   map[string]*discovery.Port
+	sent    map[string]bool
+}
+
+// NewStream returns a Stream forwarding the matching events to eventCB
+func NewStream(filter *Filter, eventCB discovery.EventCallback) *Stream {
+	return &Stream{
+		eventCB: eventCB,
+		filter:  filter,
+		ports:   map[string]*discovery.Port{},
+		sent:    map[string]bool{},
+	}
+}
+
+// Event processes an "add", "remove" or "change" event
+func (s *Stream) Event(event string, port *discovery.Port) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	id := port.Address + "|" + port.Protocol
+	switch event {
+	case "add", "change":
+		s.ports[id] = port
+		s.update(id, port, event)
+	case "remove":
+		delete(s.ports, id)
+		if s.sent[id] {
+			delete(s.sent, id)
+			s.eventCB("remove", port)
+		}
+	default:
+		s.eventCB(event, port)
+	}
+}
+
+// SetFilter changes the filter applied to the stream
+func (s *Stream) SetFilter(filter *Filter) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.filter = filter
+	for id, port := range s.ports {
+		s.update(id, port, "")
+	}
+}
+
+// update sends the events needed to bring the client in sync with the
+// current state of the port, event is the event that changed the port or
+// an empty string if only the filter has been changed
+func (s *Stream) update(id string, port *discovery.Port, event string) {
+	match := s.filter.Match(port)
+	switch {
+	case match && !s.sent[id]:
+		s.sent[id] = true
+		s.eventCB("add", port)
+	case match && event != "":
+		s.eventCB(event, port)
+	case !match && s.sent[id]:
+		delete(s.sent, id)
+		s.eventCB("remove", port)
+	}
+}
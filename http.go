@@ -0,0 +1,77 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/arduino/serial-discovery/httpapi"
+	"github.com/arduino/serial-discovery/hub"
+)
+
+// httpAddress returns the address the HTTP server listens on: if the host
+// is not specified only the local connections are accepted
+func httpAddress(address string) (string, error) {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		// Only the port has been given
+		address = ":" + address
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || port == "" {
+		return "", fmt.Errorf("invalid HTTP address '%s', expected [<host>:]<port>", address)
+	}
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// serveHTTP serves the port events over HTTP until the context is canceled
+func serveHTTP(ctx context.Context, address string, config httpapi.Config, sessions *hub.Hub) error {
+	addr, err := httpAddress(address)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	handler, err := httpapi.New(ctx, sessions.Start, config)
+	if err != nil {
+		listener.Close()
+		return err
+	}
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	if err := srv.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,308 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+// Package httpapi serves the port events over HTTP, for the clients that do
+// not speak the pluggable-discovery protocol, such as web dashboards:
+//
+//   - GET /ports returns the list of the current ports as a JSON array
+//   - GET /events streams the port events as Server-Sent Events
+//
+// The ports are serialized as in the pluggable-discovery protocol, and both
+// endpoints accept a "filter" query parameter with a filter expression (see
+// the filter package).
+//
+// The requests with a Host header that is neither an IP address, "localhost"
+// nor one of the configured host names are rejected, to protect the local
+// server from DNS rebinding attacks. The web pages served from a different
+// origin may use the endpoints only if their origin is allowed by the Config.
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	gosync "sync"
+	"time"
+
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+	"github.com/arduino/serial-discovery/filter"
+	"github.com/arduino/serial-discovery/hub"
+)
+
+// retryDelay is the delay before restarting the sync session after an error,
+// it is doubled, up to maxRetryDelay, each time the restart fails
+const retryDelay = time.Second
+
+// maxRetryDelay is the upper bound of the delay between two restarts
+const maxRetryDelay = 30 * time.Second
+
+// keepAliveInterval is the interval between the comments sent on idle event
+// streams, to keep the connections open through proxies
+const keepAliveInterval = 30 * time.Second
+
+// Config is the access configuration of a Server
+type Config struct {
+	// AllowedOrigins are the origins of the web pages allowed to use the
+	// endpoints (Access-Control-Allow-Origin), "*" allows any origin
+	AllowedOrigins []string
+	// AllowedHosts are the host names accepted in the Host header, besides
+	// the IP addresses and "localhost"
+	AllowedHosts []string
+}
+
+// Server is the HTTP handler of the port events, it must be created using
+// the New function
+type Server struct {
+	config Config
+	start  hub.StartFunc
+	mux    *http.ServeMux
+	lock   gosync.Mutex
+	ports  map[string]*discovery.Port
+	order  []string
+	err    string
+}
+
+// New creates a Server that gets the port events from the given function.
+// The sync session used to answer GET /ports runs until the context is
+// canceled.
+func New(ctx context.Context, start hub.StartFunc, config Config) (*Server, error) {
+	s := &Server{
+		config: config,
+		start:  start,
+		mux:    http.NewServeMux(),
+		ports:  map[string]*discovery.Port{},
+	}
+	s.mux.HandleFunc("GET /ports", s.handlePorts)
+	s.mux.HandleFunc("GET /events", s.handleEvents)
+	if err := s.watch(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.allowedHost(r.Host) {
+		http.Error(w, "invalid Host header", http.StatusForbidden)
+		return
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if allowOrigin := s.allowedOrigin(origin); allowOrigin != "" {
+			w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
+			w.Header().Add("Vary", "Origin")
+			if r.Method == http.MethodOptions {
+				// Preflight request
+				w.Header().Set("Access-Control-Allow-Methods", "GET")
+				w.Header().Set("Access-Control-Allow-Headers", "Cache-Control, Last-Event-ID")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// allowedHost returns true if the Host header of a request is acceptable: a
+// DNS rebinding attack always uses a host name controlled by the attacker,
+// so the IP addresses are accepted, while the names must be allowed
+// explicitly
+func (s *Server) allowedHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if net.ParseIP(host) != nil || strings.EqualFold(host, "localhost") {
+		return true
+	}
+	for _, allowed := range s.config.AllowedHosts {
+		if strings.EqualFold(host, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedOrigin returns the value of the Access-Control-Allow-Origin header
+// for the given origin, or an empty string if the origin is not allowed
+func (s *Server) allowedOrigin(origin string) string {
+	for _, allowed := range s.config.AllowedOrigins {
+		if allowed == "*" {
+			return "*"
+		}
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// watch keeps the list of the current ports, the sync session is restarted
+// if it fails
+func (s *Server) watch(ctx context.Context) error {
+	sessionCtx, cancel := context.WithCancel(ctx)
+	eventCB := func(event string, port *discovery.Port) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		id := port.Address + "|" + port.Protocol
+		switch event {
+		case "add", "change":
+			if _, ok := s.ports[id]; !ok {
+				s.order = append(s.order, id)
+			}
+			s.ports[id] = port
+		case "remove":
+			delete(s.ports, id)
+			for i, x := range s.order {
+				if x == id {
+					s.order = append(s.order[:i], s.order[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+	errorCB := func(msg string) {
+		s.lock.Lock()
+		s.err = msg
+		s.ports = map[string]*discovery.Port{}
+		s.order = nil
+		s.lock.Unlock()
+		cancel()
+		s.restart(ctx, retryDelay)
+	}
+	if err := s.start(sessionCtx, eventCB, errorCB); err != nil {
+		cancel()
+		return err
+	}
+	s.lock.Lock()
+	s.err = ""
+	s.lock.Unlock()
+	return nil
+}
+
+// restart starts a new sync session after the given delay, if it fails the
+// restart is retried with a doubled delay
+func (s *Server) restart(ctx context.Context, delay time.Duration) {
+	time.AfterFunc(delay, func() {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.watch(ctx); err != nil {
+			s.lock.Lock()
+			s.err = err.Error()
+			s.lock.Unlock()
+			s.restart(ctx, min(2*delay, maxRetryDelay))
+		}
+	})
+}
+
+func (s *Server) handlePorts(w http.ResponseWriter, r *http.Request) {
+	portFilter, err := filter.Parse(r.URL.Query().Get("filter"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.lock.Lock()
+	if s.err != "" {
+		msg := s.err
+		s.lock.Unlock()
+		http.Error(w, msg, http.StatusServiceUnavailable)
+		return
+	}
+	ports := []*discovery.Port{}
+	for _, id := range s.order {
+		if port := s.ports[id]; portFilter.Match(port) {
+			ports = append(ports, port)
+		}
+	}
+	s.lock.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ports)
+}
+
+// event is a port event, serialized as in the pluggable-discovery protocol
+type event struct {
+	EventType string          `json:"eventType"`
+	Message   string          `json:"message,omitempty"`
+	Error     bool            `json:"error,omitempty"`
+	Port      *discovery.Port `json:"port,omitempty"`
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	portFilter, err := filter.Parse(r.URL.Query().Get("filter"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	events := make(chan *event)
+	send := func(e *event) {
+		select {
+		case events <- e:
+		case <-ctx.Done():
+		}
+	}
+	stream := filter.NewStream(portFilter, func(eventType string, port *discovery.Port) {
+		send(&event{EventType: eventType, Port: port})
+	})
+	errorCB := func(msg string) {
+		send(&event{EventType: "error", Error: true, Message: msg})
+	}
+	if err := s.start(ctx, stream.Event, errorCB); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case e := <-events:
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.EventType, data)
+			if e.Error {
+				// The session has stopped, the client must reconnect
+				flusher.Flush()
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,153 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+// Package hub shares a single sync session among many subscribers: the
+// ports are enumerated and watched once, and the events are multiplexed to
+// every subscriber. A subscriber joining while the session is running
+// receives the current ports as initial "add" events, as if it had started
+// its own session.
+package hub
+
+import (
+	"context"
+	gosync "sync"
+
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+)
+
+// StartFunc starts a sync session, it has the same signature of sync.Start
+type StartFunc func(ctx context.Context, eventCB discovery.EventCallback, errorCB discovery.ErrorCallback) error
+
+// Hub is a sync session shared among many subscribers, it must be created
+// using the New function
+type Hub struct {
+	start       StartFunc
+	lock        gosync.Mutex
+	session     *session
+	subscribers map[*subscriber]bool
+}
+
+// session is a running sync session along with the ports it reported
+type session struct {
+	cancel context.CancelFunc
+	ports  map[string]*discovery.Port
+	order  []string
+}
+
+// New creates a Hub that runs its sync session with the given function.
+// The session is started when the first subscriber joins and stopped when
+// the last one leaves.
+func New(start StartFunc) *Hub {
+	return &Hub{
+		start:       start,
+		subscribers: map[*subscriber]bool{},
+	}
+}
+
+// Start subscribes to the events of the shared sync session until the
+// context is canceled, it can be used in place of sync.Start. The ports
+// already reported by the session are immediately sent to eventCB as "add"
+// events.
+func (h *Hub) Start(ctx context.Context, eventCB discovery.EventCallback, errorCB discovery.ErrorCallback) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.session == nil {
+		s := &session{ports: map[string]*discovery.Port{}}
+		sessionCtx, cancel := context.WithCancel(context.Background())
+		s.cancel = cancel
+		err := h.start(sessionCtx,
+			func(event string, port *discovery.Port) { h.event(s, event, port) },
+			func(msg string) { h.error(s, msg) })
+		if err != nil {
+			cancel()
+			return err
+		}
+		h.session = s
+	}
+
+	sub := newSubscriber(eventCB, errorCB)
+	for _, id := range h.session.order {
+		sub.event("add", h.session.ports[id])
+	}
+	h.subscribers[sub] = true
+
+	go func() {
+		<-ctx.Done()
+		h.unsubscribe(sub)
+	}()
+	return nil
+}
+
+func (h *Hub) unsubscribe(sub *subscriber) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	delete(h.subscribers, sub)
+	sub.close()
+	if len(h.subscribers) == 0 && h.session != nil {
+		h.session.cancel()
+		h.session = nil
+	}
+}
+
+// event records the port event and forwards it to all the subscribers
+func (h *Hub) event(s *session, event string, port *discovery.Port) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.session != s {
+		// Late event of a stopped session
+		return
+	}
+	id := port.Address + "|" + port.Protocol
+	switch event {
+	case "add", "change":
+		if _, ok := s.ports[id]; !ok {
+			s.order = append(s.order, id)
+		}
+		s.ports[id] = port
+	case "remove":
+		delete(s.ports, id)
+		for i, x := range s.order {
+			if x == id {
+				s.order = append(s.order[:i], s.order[i+1:]...)
+				break
+			}
+		}
+	}
+	for sub := range h.subscribers {
+		sub.event(event, port)
+	}
+}
+
+// error forwards the error to all the subscribers. After an error the
+// session sends no more events, so it is stopped and the subscribers are
+// detached: they are expected to stop and start again, and the first one
+// to start again starts a new session.
+func (h *Hub) error(s *session, msg string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.session != s {
+		return
+	}
+	s.cancel()
+	h.session = nil
+	for sub := range h.subscribers {
+		sub.error(msg)
+		sub.finish()
+		delete(h.subscribers, sub)
+	}
+}
@@ -0,0 +1,96 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package hub
+
+import (
+	gosync "sync"
+
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+)
+
+// subscriber delivers the events to a subscriber from its own goroutine, so
+// that a slow client does not delay the others. The events are queued in
+// order and the queue is discarded when the subscriber leaves.
+type subscriber struct {
+	eventCB discovery.EventCallback
+	errorCB discovery.ErrorCallback
+	lock    gosync.Mutex
+	cond    *gosync.Cond
+	queue   []func()
+	done    bool
+	closed  bool
+}
+
+func newSubscriber(eventCB discovery.EventCallback, errorCB discovery.ErrorCallback) *subscriber {
+	sub := &subscriber{eventCB: eventCB, errorCB: errorCB}
+	sub.cond = gosync.NewCond(&sub.lock)
+	go sub.run()
+	return sub
+}
+
+func (s *subscriber) event(event string, port *discovery.Port) {
+	s.push(func() { s.eventCB(event, port) })
+}
+
+func (s *subscriber) error(msg string) {
+	s.push(func() { s.errorCB(msg) })
+}
+
+func (s *subscriber) push(f func()) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed || s.done {
+		return
+	}
+	s.queue = append(s.queue, f)
+	s.cond.Signal()
+}
+
+// finish stops the subscriber after the delivery of the queued events
+func (s *subscriber) finish() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.done = true
+	s.cond.Signal()
+}
+
+// close stops the subscriber discarding the queued events
+func (s *subscriber) close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closed = true
+	s.queue = nil
+	s.cond.Signal()
+}
+
+func (s *subscriber) run() {
+	for {
+		s.lock.Lock()
+		for len(s.queue) == 0 && !s.closed && !s.done {
+			s.cond.Wait()
+		}
+		if s.closed || len(s.queue) == 0 {
+			s.lock.Unlock()
+			return
+		}
+		f := s.queue[0]
+		s.queue = s.queue[1:]
+		s.lock.Unlock()
+		f()
+	}
+}
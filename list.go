@@ -0,0 +1,136 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"text/template"
+
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+	"github.com/arduino/serial-discovery/filter"
+	"github.com/arduino/serial-discovery/sync"
+)
+
+// runList implements the "list" command: the ports are enumerated once and
+// printed in the requested format. The exit code is 1 if a filter is given
+// and no port matches it.
+func runList(format string, portFilter *filter.Filter) int {
+	printer, err := newPortPrinter(format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid argument: %s\n", err)
+		return 1
+	}
+	ports, err := sync.List(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
+	matching := []*discovery.Port{}
+	for _, port := range ports {
+		if portFilter.Match(port) {
+			matching = append(matching, port)
+		}
+	}
+	if err := printer(os.Stdout, matching); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
+	if portFilter != nil && len(matching) == 0 {
+		return 1
+	}
+	return 0
+}
+
+// portPrinter outputs a list of ports
+type portPrinter func(w io.Writer, ports []*discovery.Port) error
+
+// newPortPrinter returns the printer of the given format: "json", "ndjson",
+// "table" or a text/template executed for each port
+func newPortPrinter(format string) (portPrinter, error) {
+	switch format {
+	case "json":
+		return printJSON, nil
+	case "ndjson":
+		return printNDJSON, nil
+	case "table":
+		return printTable, nil
+	}
+	tmpl, err := template.New("format").Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("invalid format: %w", err)
+	}
+	return func(w io.Writer, ports []*discovery.Port) error {
+		for _, port := range ports {
+			if err := tmpl.Execute(w, port); err != nil {
+				return err
+			}
+			fmt.Fprintln(w)
+		}
+		return nil
+	}, nil
+}
+
+func printJSON(w io.Writer, ports []*discovery.Port) error {
+	data, err := json.MarshalIndent(ports, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+func printNDJSON(w io.Writer, ports []*discovery.Port) error {
+	enc := json.NewEncoder(w)
+	for _, port := range ports {
+		if err := enc.Encode(port); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printTable(w io.Writer, ports []*discovery.Port) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ADDRESS\tPROTOCOL\tVID\tPID\tSERIAL\tBOARD")
+	for _, port := range ports {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			port.Address,
+			port.Protocol,
+			port.Properties.Get("vid"),
+			port.Properties.Get("pid"),
+			port.Properties.Get("serialNumber"),
+			portDescription(port))
+	}
+	return tw.Flush()
+}
+
+// portDescription returns a human readable description of the device
+// connected to the port
+func portDescription(port *discovery.Port) string {
+	for _, key := range []string{"boardName", "product", "productName", "uf2Model"} {
+		if value := port.Properties.Get(key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,129 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/arduino/serial-discovery/filter"
+	"github.com/arduino/serial-discovery/hub"
+	"github.com/arduino/serial-discovery/server"
+	"github.com/arduino/serial-discovery/sync"
+)
+
+// parseListenAddress splits a listen address in the form "unix://<path>" or
+// "tcp://<host>:<port>" in the network and address expected by net.Listen
+func parseListenAddress(address string) (string, string, error) {
+	if path, ok := strings.CutPrefix(address, "unix://"); ok && path != "" {
+		return "unix", path, nil
+	}
+	if hostPort, ok := strings.CutPrefix(address, "tcp://"); ok {
+		if _, _, err := net.SplitHostPort(hostPort); err != nil {
+			return "", "", fmt.Errorf("invalid listen address '%s': %w", address, err)
+		}
+		return "tcp", hostPort, nil
+	}
+	return "", "", fmt.Errorf("invalid listen address '%s', expected unix://<path> or tcp://<host>:<port>", address)
+}
+
+// listen serves the pluggable-discovery protocol to each client connecting
+// to the given address, until the context is canceled. All the clients
+// share the same sync session.
+func listen(ctx context.Context, address string, sessions *hub.Hub, portFilter *filter.Filter) error {
+	network, addr, err := parseListenAddress(address)
+	if err != nil {
+		return err
+	}
+	if network == "unix" {
+		removeStaleSocket(addr)
+	}
+	listener, err := net.Listen(network, addr)
+	if err != nil {
+		return err
+	}
+
+	// Close the listener on exit, this also removes the Unix socket
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		go serveConnection(conn, sessions, portFilter)
+	}
+}
+
+// serveConnection runs a pluggable-discovery session on the connection
+func serveConnection(conn net.Conn, sessions *hub.Hub, portFilter *filter.Filter) {
+	defer conn.Close()
+	client := conn.RemoteAddr().String()
+	if client == "" || client == "@" {
+		client = conn.LocalAddr().String()
+	}
+	connectionDiagnostic("client_connected", "client connected", client)
+
+	serialDisc := &SerialDiscovery{filter: portFilter, startSync: sessions.Start}
+	err := server.New(serialDisc).Run(conn, conn)
+	// The client may disconnect without sending STOP or QUIT
+	serialDisc.Stop()
+	if err != nil && !errors.Is(err, io.EOF) {
+		connectionDiagnostic("client_error", err.Error(), client)
+		return
+	}
+	connectionDiagnostic("client_disconnected", "client disconnected", client)
+}
+
+// removeStaleSocket removes the Unix socket left behind by a previous
+// instance that has not been shut down cleanly. A socket that still
+// accepts connections is left in place, so that net.Listen fails.
+func removeStaleSocket(path string) {
+	if info, err := os.Stat(path); err != nil || info.Mode().Type() != os.ModeSocket {
+		return
+	}
+	conn, err := net.Dial("unix", path)
+	if err == nil {
+		conn.Close()
+		return
+	}
+	os.Remove(path)
+}
+
+func connectionDiagnostic(event, message, client string) {
+	if sync.DiagnosticCB == nil {
+		return
+	}
+	sync.DiagnosticCB(&sync.Diagnostic{
+		Time:    time.Now(),
+		Event:   event,
+		Message: message,
+		Port:    client,
+	})
+}
@@ -20,12 +20,23 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
 	"github.com/arduino/serial-discovery/args"
+	"github.com/arduino/serial-discovery/boards"
+	"github.com/arduino/serial-discovery/filter"
+	"github.com/arduino/serial-discovery/httpapi"
+	"github.com/arduino/serial-discovery/hub"
+	"github.com/arduino/serial-discovery/rules"
+	"github.com/arduino/serial-discovery/server"
 	"github.com/arduino/serial-discovery/sync"
+	"github.com/arduino/serial-discovery/usbids"
 	"github.com/arduino/serial-discovery/version"
 )
 
@@ -35,23 +46,184 @@ func main() {
 		fmt.Printf("%s\n", version.VersionInfo)
 		return
 	}
+	sync.EnumerationRetryPolicy = sync.RetryPolicy{
+		Attempts: args.RetryAttempts,
+		Delay:    args.RetryDelay,
+		MaxDelay: args.RetryMaxDelay,
+	}
+	sync.StableAfter = args.StableAfter
+	sync.RemoveGrace = args.RemoveGrace
+	sync.CorrelationWindow = args.CorrelationWindow
+	if err := sync.ValidateHardwareIDMethods(args.HardwareIDMethods); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid argument: %s\n", err)
+		os.Exit(1)
+	}
+	sync.HardwareIDMethods = args.HardwareIDMethods
+	if len(args.BoardsDirs) > 0 {
+		index, err := boards.Load(args.BoardsDirs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: loading boards: %s\n", err)
+		}
+		sync.Boards = index
+	}
+	if args.RulesPath != "" {
+		portRules, err := rules.Load(args.RulesPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading rules: %s\n", err)
+			os.Exit(1)
+		}
+		sync.Rules = portRules
+	}
+	sync.ReportUnbound = args.ReportUnbound
+	sync.UF2Drives = args.UF2Drives
+	sync.DFUDevices = args.DFUDevices
+	if !args.NoUSBIDs {
+		var db *usbids.Database
+		var err error
+		if args.USBIDsPath != "" {
+			db, err = usbids.Load(args.USBIDsPath)
+		} else {
+			db, err = usbids.LoadDefault()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading usb.ids database: %s\n", err)
+			os.Exit(1)
+		}
+		sync.USBIDs = db
+	}
+	if args.Diagnostics {
+		sync.DiagnosticCB = printDiagnostic
+	}
+
+	portFilter, err := filter.Parse(args.Filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid argument: %s\n", err)
+		os.Exit(1)
+	}
+
+	switch args.Command {
+	case "list":
+		os.Exit(runList(args.Format, portFilter))
+	case "watch":
+		os.Exit(runWatch(args.Color, args.Columns, portFilter))
+	case "wait-for":
+		os.Exit(runWaitFor(args.Match, args.Gone, args.Timeout, args.Format, portFilter))
+	}
 
-	serialDisc := &SerialDiscovery{}
-	disc := discovery.NewServer(serialDisc)
+	if args.Listen != "" || args.HTTP != "" {
+		if err := serveDaemon(portFilter); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	serialDisc := &SerialDiscovery{filter: portFilter}
+	disc := server.New(serialDisc)
 	if err := disc.Run(os.Stdin, os.Stdout); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
 		os.Exit(1)
 	}
 }
 
+// serveDaemon runs the servers enabled on the command line, sharing the
+// same sync session, until the process is interrupted or one of them fails
+func serveDaemon(portFilter *filter.Filter) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	sessions := hub.New(sync.Start)
+	errs := make(chan error, 2)
+	running := 0
+	if args.Listen != "" {
+		running++
+		go func() { errs <- listen(ctx, args.Listen, sessions, portFilter) }()
+	}
+	if args.HTTP != "" {
+		running++
+		config := httpapi.Config{AllowedOrigins: args.HTTPAllowOrigins, AllowedHosts: args.HTTPAllowHosts}
+		go func() { errs <- serveHTTP(ctx, args.HTTP, config, sessions) }()
+	}
+	var res error
+	for ; running > 0; running-- {
+		if err := <-errs; err != nil && res == nil {
+			res = err
+			stop()
+		}
+	}
+	return res
+}
+
+// printDiagnostic outputs a diagnostic as a single JSON line on stderr,
+// stdout is reserved to the pluggable-discovery protocol
+func printDiagnostic(d *sync.Diagnostic) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return
+	}
+	os.Stderr.Write(append(data, '\n'))
+}
+
+// maxProtocolVersion is the newest pluggable-discovery protocol version
+// supported. Version 2 adds:
+// - "change" events, sent when the attributes of a connected port change
+// - "remove" events carrying the full port metadata
+// - the FILTER command
+const maxProtocolVersion = 2
+
 // SerialDiscovery is the implementation of the serial ports pluggable-discovery
 type SerialDiscovery struct {
-	stopSync context.CancelFunc
+	stopSync        context.CancelFunc
+	startSync       hub.StartFunc
+	userAgent       string
+	protocolVersion int
+	filter          *filter.Filter
+	stream          *filter.Stream
 }
 
-// Hello is the handler for the pluggable-discovery HELLO command
-// revive:disable:unused-parameter
+// Hello is the handler for the pluggable-discovery HELLO command, it
+// negotiates the protocol version: the highest version supported by both
+// the client and the discovery is used.
 func (d *SerialDiscovery) Hello(userAgent string, protocolVersion int) error {
+	if protocolVersion < 1 {
+		return fmt.Errorf("unsupported protocol version %d, the supported versions are 1 to %d", protocolVersion, maxProtocolVersion)
+	}
+	d.userAgent = userAgent
+	d.protocolVersion = min(protocolVersion, maxProtocolVersion)
+	if sync.DiagnosticCB != nil {
+		sync.DiagnosticCB(&sync.Diagnostic{
+			Time:    time.Now(),
+			Event:   "hello",
+			Message: fmt.Sprintf("client %q requested protocol version %d, using version %d", userAgent, protocolVersion, d.protocolVersion),
+		})
+	}
+	return nil
+}
+
+// ProtocolVersion returns the protocol version negotiated in Hello
+func (d *SerialDiscovery) ProtocolVersion() int {
+	return d.protocolVersion
+}
+
+// Capabilities returns the protocol extensions enabled by the negotiated
+// protocol version
+func (d *SerialDiscovery) Capabilities() []string {
+	if d.protocolVersion < 2 {
+		return nil
+	}
+	return []string{"change_events", "rich_remove", "filter"}
+}
+
+// SetFilter is the handler for the pluggable-discovery FILTER command
+func (d *SerialDiscovery) SetFilter(expr string) error {
+	f, err := filter.Parse(expr)
+	if err != nil {
+		return err
+	}
+	d.filter = f
+	if d.stream != nil {
+		d.stream.SetFilter(f)
+	}
 	return nil
 }
 
@@ -65,16 +237,51 @@ func (d *SerialDiscovery) Stop() error {
 		d.stopSync()
 		d.stopSync = nil
 	}
+	d.stream = nil
 	return nil
 }
 
 // StartSync is the handler for the pluggable-discovery START_SYNC command
 func (d *SerialDiscovery) StartSync(eventCB discovery.EventCallback, errorCB discovery.ErrorCallback) error {
 	ctx, cancel := context.WithCancel(context.Background())
-	if err := sync.Start(ctx, eventCB, errorCB); err != nil {
+	if d.protocolVersion < 2 {
+		eventCB = translateEvents(eventCB)
+	}
+	stream := filter.NewStream(d.filter, eventCB)
+	start := d.startSync
+	if start == nil {
+		start = sync.Start
+	}
+	if err := start(ctx, stream.Event, errorCB); err != nil {
 		cancel()
 		return err
 	}
 	d.stopSync = cancel
+	d.stream = stream
 	return nil
 }
+
+// translateEvents adapts the events generated by the sync package to the
+// pluggable-discovery protocol version 1: "change" events are not part of
+// the protocol, so they are sent as a "remove" followed by an "add", and
+// "remove" events carry only the address and protocol of the port.
+func translateEvents(eventCB discovery.EventCallback) discovery.EventCallback {
+	return func(event string, port *discovery.Port) {
+		switch event {
+		case "change":
+			eventCB("remove", removedPort(port))
+			eventCB("add", port)
+		case "remove":
+			eventCB("remove", removedPort(port))
+		default:
+			eventCB(event, port)
+		}
+	}
+}
+
+func removedPort(port *discovery.Port) *discovery.Port {
+	return &discovery.Port{
+		Address:  port.Address,
+		Protocol: port.Protocol,
+	}
+}
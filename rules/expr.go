@@ -0,0 +1,357 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package rules
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+	"github.com/arduino/serial-discovery/filter"
+)
+
+// Expr is a parsed boolean expression evaluated against a port
+type Expr interface {
+	// Eval returns the result of the expression for the given port
+	Eval(port *discovery.Port) bool
+}
+
+// ParseExpr parses an expression. The grammar is:
+//
+//	expr       = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | "(" expr ")" | comparison
+//	comparison = operand [ ( "==" | "!=" | "matches" ) operand ]
+//	operand    = field | string
+//	field      = "address" | "label" | "protocol" | "protocolLabel" | "hardwareId" | "properties." key
+//
+// Strings are enclosed in double quotes and support the usual backslash
+// escapes. "matches" compares the left operand with the glob pattern on the
+// right (see path.Match). The values of the "vid" and "pid" properties are
+// compared ignoring case and the "0x" prefix. An operand used alone is true
+// if its value is not empty, so "!properties.serialNumber" is true for the
+// ports without a serial number.
+func ParseExpr(src string) (Expr, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected '%s' at position %d", tok.text, tok.pos+1)
+	}
+	return expr, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"':
+			start := i
+			var value strings.Builder
+			i++
+			for ; i < len(src) && src[i] != '"'; i++ {
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+					switch src[i] {
+					case 'n':
+						value.WriteByte('\n')
+					case 't':
+						value.WriteByte('\t')
+					default:
+						value.WriteByte(src[i])
+					}
+					continue
+				}
+				value.WriteByte(src[i])
+			}
+			if i >= len(src) {
+				return nil, fmt.Errorf("unterminated string at position %d", start+1)
+			}
+			i++
+			tokens = append(tokens, token{kind: tokenString, text: value.String(), pos: start})
+		case strings.HasPrefix(src[i:], "&&"), strings.HasPrefix(src[i:], "||"),
+			strings.HasPrefix(src[i:], "=="), strings.HasPrefix(src[i:], "!="):
+			tokens = append(tokens, token{kind: tokenOp, text: src[i : i+2], pos: i})
+			i += 2
+		case c == '!' || c == '(' || c == ')':
+			tokens = append(tokens, token{kind: tokenOp, text: string(c), pos: i})
+			i++
+		case isIdentChar(c):
+			start := i
+			for i < len(src) && isIdentChar(rune(src[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: src[start:i], pos: start})
+		default:
+			return nil, fmt.Errorf("unexpected character '%c' at position %d", c, i+1)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(src)}), nil
+}
+
+func isIdentChar(c rune) bool {
+	return c < unicode.MaxASCII && (unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' || c == '.' || c == '-')
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) isOp(text string) bool {
+	tok := p.peek()
+	return tok.kind == tokenOp && tok.text == text
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("&&") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	switch {
+	case p.isOp("!"):
+		p.next()
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{expr}, nil
+	case p.isOp("("):
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.isOp(")") {
+			tok := p.peek()
+			return nil, fmt.Errorf("expected ')' at position %d", tok.pos+1)
+		}
+		p.next()
+		return expr, nil
+	default:
+		return p.parseComparison()
+	}
+}
+
+func (p *parser) parseComparison() (Expr, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	var op string
+	switch tok := p.peek(); {
+	case tok.kind == tokenOp && (tok.text == "==" || tok.text == "!="):
+		op = tok.text
+	case tok.kind == tokenIdent && tok.text == "matches":
+		op = tok.text
+	default:
+		return &truthyExpr{left}, nil
+	}
+	p.next()
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if op == "matches" {
+		lit, ok := right.(literal)
+		if !ok {
+			return nil, fmt.Errorf("the pattern of 'matches' must be a string")
+		}
+		if _, err := path.Match(string(lit), ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern \"%s\"", lit)
+		}
+	}
+	// The VIDs and PIDs are compared as in the filters, ignoring the case
+	// and the "0x" prefix
+	key, ok := left.(property)
+	if !ok {
+		key, _ = right.(property)
+	}
+	return &compareExpr{op: op, key: string(key), left: left, right: right}, nil
+}
+
+func (p *parser) parseOperand() (operand, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenString:
+		return literal(tok.text), nil
+	case tokenIdent:
+		switch tok.text {
+		case "address", "label", "protocol", "protocolLabel", "hardwareId":
+			return field(tok.text), nil
+		}
+		if key, ok := strings.CutPrefix(tok.text, "properties."); ok && key != "" {
+			return property(key), nil
+		}
+		return nil, fmt.Errorf("unknown field '%s' at position %d", tok.text, tok.pos+1)
+	case tokenEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	default:
+		return nil, fmt.Errorf("unexpected '%s' at position %d", tok.text, tok.pos+1)
+	}
+}
+
+// operand is a value taken from the port or a literal string
+type operand interface {
+	value(port *discovery.Port) string
+}
+
+type literal string
+
+func (l literal) value(_ *discovery.Port) string {
+	return string(l)
+}
+
+type field string
+
+func (f field) value(port *discovery.Port) string {
+	switch f {
+	case "address":
+		return port.Address
+	case "label":
+		return port.AddressLabel
+	case "protocol":
+		return port.Protocol
+	case "protocolLabel":
+		return port.ProtocolLabel
+	case "hardwareId":
+		return port.HardwareID
+	}
+	return ""
+}
+
+type property string
+
+func (p property) value(port *discovery.Port) string {
+	if port.Properties == nil {
+		return ""
+	}
+	return port.Properties.Get(string(p))
+}
+
+type orExpr struct{ left, right Expr }
+
+func (e *orExpr) Eval(port *discovery.Port) bool {
+	return e.left.Eval(port) || e.right.Eval(port)
+}
+
+type andExpr struct{ left, right Expr }
+
+func (e *andExpr) Eval(port *discovery.Port) bool {
+	return e.left.Eval(port) && e.right.Eval(port)
+}
+
+type notExpr struct{ expr Expr }
+
+func (e *notExpr) Eval(port *discovery.Port) bool {
+	return !e.expr.Eval(port)
+}
+
+type truthyExpr struct{ operand operand }
+
+func (e *truthyExpr) Eval(port *discovery.Port) bool {
+	return e.operand.value(port) != ""
+}
+
+type compareExpr struct {
+	op string
+	// key is the property compared, used to normalize the values
+	key         string
+	left, right operand
+}
+
+func (e *compareExpr) Eval(port *discovery.Port) bool {
+	left := filter.Normalize(e.key, e.left.value(port))
+	right := filter.Normalize(e.key, e.right.value(port))
+	switch e.op {
+	case "==":
+		return left == right
+	case "!=":
+		return left != right
+	case "matches":
+		ok, _ := path.Match(right, left)
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package rules
+
+import (
+	"testing"
+
+	"github.com/arduino/go-properties-orderedmap"
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+)
+
+func testPort() *discovery.Port {
+	props := properties.NewMap()
+	props.Set("vid", "0x1a86")
+	props.Set("pid", "0x7523")
+	props.Set("product", "USB \"Serial\"")
+	return &discovery.Port{
+		Address:       "/dev/ttyUSB0",
+		AddressLabel:  "/dev/ttyUSB0",
+		Protocol:      "serial",
+		ProtocolLabel: "Serial Port (USB)",
+		Properties:    props,
+	}
+}
+
+func TestExprEval(t *testing.T) {
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		// Comparisons
+		{`address == "/dev/ttyUSB0"`, true},
+		{`address != "/dev/ttyUSB0"`, false},
+		{`"/dev/ttyUSB0" == address`, true},
+		{`address matches "/dev/ttyUSB*"`, true},
+		{`address matches "/dev/ttyACM*"`, false},
+		{`properties.missing == ""`, true},
+		// VID and PID are compared ignoring case and the "0x" prefix
+		{`properties.vid == "0x1A86"`, true},
+		{`properties.vid == "1a86"`, true},
+		{`"0X1A86" == properties.vid`, true},
+		{`properties.pid != "7523"`, false},
+		{`properties.vid matches "0x1A*"`, true},
+		{`properties.product == "usb \"serial\""`, false},
+		// Truthiness
+		{`properties.vid`, true},
+		{`!properties.serialNumber`, true},
+		{`hardwareId`, false},
+		// Precedence: ! binds tighter than &&, that binds tighter than ||
+		{`protocol == "dfu" && address == "x" || protocol == "serial"`, true},
+		{`protocol == "serial" || protocol == "dfu" && address == "x"`, true},
+		{`(protocol == "serial" || protocol == "dfu") && address == "x"`, false},
+		{`!protocol == "dfu" && address == "x"`, false},
+		{`!(protocol == "dfu" && address == "x")`, true},
+		{`!!properties.vid`, true},
+		// Quoting
+		{`properties.product == "USB \"Serial\""`, true},
+		{`label == "/dev/ttyUSB0" && protocolLabel == "Serial Port (USB)"`, true},
+		{`address == "&& || ( )"`, false},
+	}
+	port := testPort()
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			expr, err := ParseExpr(test.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := expr.Eval(port); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseExprErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{``, "unexpected end of expression"},
+		{`address ==`, "unexpected end of expression"},
+		{`address == "x`, "unterminated string at position 12"},
+		{`(address == "x"`, "expected ')' at position 16"},
+		{`address == "x")`, "unexpected ')' at position 15"},
+		{`address = "x"`, "unexpected character '=' at position 9"},
+		{`address & "x"`, "unexpected character '&' at position 9"},
+		{`port == "x"`, "unknown field 'port' at position 1"},
+		{`properties. == "x"`, "unknown field 'properties.' at position 1"},
+		{`address == "x" &&`, "unexpected end of expression"},
+		{`address matches protocol`, "the pattern of 'matches' must be a string"},
+		{`address matches "["`, `invalid pattern "["`},
+		{`address "x"`, "unexpected 'x' at position 9"},
+	}
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			_, err := ParseExpr(test.expr)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if err.Error() != test.err {
+				t.Errorf("got error %q, want %q", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+// Package rules implements the include/exclude rules used to hide ports
+// from the clients, for example the modems or the 3D printers connected to
+// a machine that must never be offered as upload targets.
+//
+// A rules file contains one rule per line in the form "include: <expr>" or
+// "exclude: <expr>", where <expr> is a boolean expression (see ParseExpr).
+// Empty lines and lines starting with "#" are ignored:
+//
+//	# Hide the built-in serial ports
+//	exclude: address matches "/dev/ttyS*"
+//	# Hide the CH340 adapters without a serial number
+//	exclude: properties.vid == "0x1a86" && !properties.serialNumber
+package rules
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+)
+
+// RuleSet is a list of include and exclude rules. A port is allowed if it
+// matches none of the exclude rules and, when there are include rules, if
+// it matches at least one of them.
+type RuleSet struct {
+	include []Expr
+	exclude []Expr
+}
+
+// Load reads the rules file at the given path
+func Load(path string) (*RuleSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// Parse parses the content of a rules file
+func Parse(data []byte) (*RuleSet, error) {
+	rules := &RuleSet{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kind, src, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected 'include:' or 'exclude:'", lineNum)
+		}
+		expr, err := ParseExpr(src)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		switch strings.TrimSpace(kind) {
+		case "include":
+			rules.include = append(rules.include, expr)
+		case "exclude":
+			rules.exclude = append(rules.exclude, expr)
+		default:
+			return nil, fmt.Errorf("line %d: unknown rule '%s', expected 'include' or 'exclude'", lineNum, strings.TrimSpace(kind))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Allow returns true if the port is allowed by the rules, a nil RuleSet
+// allows all the ports
+func (r *RuleSet) Allow(port *discovery.Port) bool {
+	if r == nil {
+		return true
+	}
+	for _, expr := range r.exclude {
+		if expr.Eval(port) {
+			return false
+		}
+	}
+	if len(r.include) == 0 {
+		return true
+	}
+	for _, expr := range r.include {
+		if expr.Eval(port) {
+			return true
+		}
+	}
+	return false
+}
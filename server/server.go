@@ -0,0 +1,283 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+// Package server serves the pluggable-discovery protocol through the Server
+// of the pluggable-discovery-protocol-handler library, adding what the
+// library does not support yet:
+//   - the protocol version requested by the client is passed to
+//     Discovery.Hello and, if the discovery implements the Negotiator
+//     interface, the negotiated version and the supported capabilities are
+//     reported in the HELLO response instead of version 1
+//   - the FILTER command, for the discoveries implementing Filterer
+//   - "change" events update the ports returned by LIST
+//   - a failed write, for example because the client went away, ends Run
+//     with an error instead of a panic
+//
+// The commands and the responses pass through the library unchanged, with
+// the exception of FILTER, handled here, and of the HELLO response.
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+)
+
+// Negotiator is implemented by the discoveries supporting protocol versions
+// other than 1. The methods are called after a successful Hello.
+type Negotiator interface {
+	// ProtocolVersion returns the protocol version negotiated with the client
+	ProtocolVersion() int
+
+	// Capabilities returns the protocol extensions enabled by the negotiated
+	// protocol version
+	Capabilities() []string
+}
+
+// Filterer is implemented by the discoveries supporting the FILTER command,
+// the command is accepted only if the "filter" capability is enabled by the
+// negotiated protocol version.
+type Filterer interface {
+	// SetFilter restricts the ports reported to the client to the ones
+	// matching the given filter expression, an empty expression removes
+	// the filter.
+	SetFilter(expr string) error
+}
+
+// A Server is a pluggable discovery protocol handler,
+// it must be created using the New function.
+type Server struct {
+	impl discovery.Discovery
+	// requestedVersion is the protocol version requested by the client
+	requestedVersion int
+	capabilities     []string
+	initialized      bool
+	// command is the command being executed by the library
+	command string
+	input   *bufio.Reader
+	pending []byte
+	// listMutex is locked while the library executes LIST, so that the
+	// cache of the ports is not updated at the same time
+	listMutex sync.Mutex
+	listing   bool
+	output    io.Writer
+	// awaitingHello is true while the response to HELLO has not been sent
+	awaitingHello bool
+	outputErr     error
+	outputMutex   sync.Mutex
+}
+
+// New creates a new discovery server backed by the provided pluggable
+// discovery implementation. To start the server use the Run method.
+func New(impl discovery.Discovery) *Server {
+	return &Server{
+		impl: impl,
+	}
+}
+
+// Run starts the protocol handling loop on the given input and
+// output stream, usually `os.Stdin` and `os.Stdout` are used.
+// The function blocks until the `QUIT` command is received or
+// the input stream is closed. In case of IO error the error is
+// returned.
+func (s *Server) Run(in io.Reader, out io.Writer) error {
+	s.input = bufio.NewReader(in)
+	s.output = out
+	return discovery.NewServer(&adapter{s}).Run(commandReader{s}, responseWriter{s})
+}
+
+// commandReader feeds the library with the commands of the client, one
+// command at a time: the library asks for the next command only when the
+// previous one has been executed.
+type commandReader struct {
+	s *Server
+}
+
+func (r commandReader) Read(p []byte) (int, error) {
+	s := r.s
+	if len(s.pending) == 0 {
+		if s.listing {
+			s.listing = false
+			s.listMutex.Unlock()
+		}
+		line, err := s.nextCommand()
+		if err != nil {
+			return 0, err
+		}
+		s.pending = line
+	}
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+// nextCommand reads the next command to be executed by the library,
+// executing the FILTER commands
+func (s *Server) nextCommand() ([]byte, error) {
+	for {
+		if err := s.outputError(); err != nil {
+			return nil, err
+		}
+		line, err := s.input.ReadString('\n')
+		if line == "" {
+			return nil, err
+		}
+		args := strings.Fields(line)
+		s.command = ""
+		if len(args) > 0 {
+			s.command = strings.ToUpper(args[0])
+		}
+
+		switch s.command {
+		case "HELLO":
+			if len(args) > 1 {
+				s.requestedVersion, _ = strconv.Atoi(args[1])
+			}
+			s.outputMutex.Lock()
+			s.awaitingHello = !s.initialized
+			s.outputMutex.Unlock()
+		case "FILTER":
+			if s.initialized {
+				s.filter(strings.TrimSpace(strings.TrimSpace(line)[len(args[0]):]))
+				continue
+			}
+		case "LIST":
+			s.listMutex.Lock()
+			s.listing = true
+		}
+		return []byte(line), nil
+	}
+}
+
+func (s *Server) filter(expr string) {
+	f, ok := s.impl.(Filterer)
+	if !ok || !slices.Contains(s.capabilities, "filter") {
+		s.send(messageError("command_error", "Command FILTER not supported"))
+		return
+	}
+	if err := f.SetFilter(expr); err != nil {
+		s.send(messageError("filter", err.Error()))
+		return
+	}
+	s.send(messageOk("filter"))
+}
+
+// responseWriter sends the responses and the events of the library to the
+// client, completing the HELLO response with the negotiated version
+type responseWriter struct {
+	s *Server
+}
+
+func (w responseWriter) Write(data []byte) (int, error) {
+	s := w.s
+	s.outputMutex.Lock()
+	defer s.outputMutex.Unlock()
+	if s.awaitingHello {
+		var msg message
+		if json.Unmarshal(data, &msg) == nil && msg.EventType == "hello" {
+			s.awaitingHello = false
+			if !msg.Error {
+				s.initialized = true
+				if n, ok := s.impl.(Negotiator); ok {
+					s.capabilities = n.Capabilities()
+					msg.ProtocolVersion = n.ProtocolVersion()
+					msg.Capabilities = s.capabilities
+					s.write(&msg)
+					return len(data), nil
+				}
+			}
+		}
+	}
+	s.writeData(data)
+	// The library panics on write errors, they are reported by Run instead
+	return len(data), nil
+}
+
+func (s *Server) send(msg *message) {
+	s.outputMutex.Lock()
+	defer s.outputMutex.Unlock()
+	s.write(msg)
+}
+
+// write sends a message, it must be called with outputMutex locked
+func (s *Server) write(msg *message) {
+	data, err := json.MarshalIndent(msg, "", "  ")
+	if err != nil {
+		// We are certain that this will be marshalled correctly
+		// so we don't handle the error
+		data, _ = json.MarshalIndent(messageError("command_error", err.Error()), "", "  ")
+	}
+	s.writeData(append(data, '\n'))
+}
+
+// writeData sends raw data, it must be called with outputMutex locked
+func (s *Server) writeData(data []byte) {
+	if s.outputErr != nil {
+		return
+	}
+	if _, err := s.output.Write(data); err != nil {
+		// The client is gone, Run returns the error
+		s.outputErr = err
+	}
+}
+
+func (s *Server) outputError() error {
+	s.outputMutex.Lock()
+	defer s.outputMutex.Unlock()
+	return s.outputErr
+}
+
+// adapter is the Discovery given to the library, it passes the requested
+// protocol version to Hello and adapts the events for the LIST cache
+type adapter struct {
+	s *Server
+}
+
+func (a *adapter) Hello(userAgent string, _ int) error {
+	return a.s.impl.Hello(userAgent, a.s.requestedVersion)
+}
+
+func (a *adapter) StartSync(eventCB discovery.EventCallback, errorCB discovery.ErrorCallback) error {
+	if a.s.command == "START" {
+		// The events update the cache of the ports returned by LIST, that
+		// knows only "add" and "remove"
+		cacheCB := eventCB
+		eventCB = func(event string, port *discovery.Port) {
+			a.s.listMutex.Lock()
+			defer a.s.listMutex.Unlock()
+			if event == "change" {
+				event = "add"
+			}
+			cacheCB(event, port)
+		}
+	}
+	return a.s.impl.StartSync(eventCB, errorCB)
+}
+
+func (a *adapter) Stop() error {
+	return a.s.impl.Stop()
+}
+
+func (a *adapter) Quit() {
+	a.s.impl.Quit()
+}
@@ -0,0 +1,52 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	"fmt"
+
+	"github.com/arduino/go-properties-orderedmap"
+	"github.com/arduino/serial-discovery/boards"
+)
+
+// Boards is used, if not nil, to identify the boards connected to the
+// USB ports
+var Boards *boards.Index
+
+// setBoardProperties adds the name and FQBN of the boards matching the
+// given VID/PID. If more than one board matches, the first one is reported
+// in "boardName" and "fqbn" and all the candidates are listed in
+// "boardName.N" and "fqbn.N".
+func setBoardProperties(props *properties.Map, vid, pid string) {
+	if Boards == nil {
+		return
+	}
+	matches := Boards.Match(vid, pid)
+	if len(matches) == 0 {
+		return
+	}
+	props.Set("boardName", matches[0].Name)
+	props.Set("fqbn", matches[0].FQBN)
+	if len(matches) == 1 {
+		return
+	}
+	for i, board := range matches {
+		props.Set(fmt.Sprintf("boardName.%d", i), board.Name)
+		props.Set(fmt.Sprintf("fqbn.%d", i), board.FQBN)
+	}
+}
@@ -0,0 +1,115 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/arduino/go-properties-orderedmap"
+	"go.bug.st/serial/enumerator"
+)
+
+// bridgeChip describes a family of USB-to-serial bridge chips
+type bridgeChip struct {
+	chip string
+	// variants maps the bcdDevice to the chip variant
+	variants map[string]string
+	// channels is true for chips with more than one serial channel
+	channels bool
+}
+
+// bridgeChips maps the USB VID:PID (lowercase hex) to the bridge chip
+var bridgeChips = map[string]*bridgeChip{
+	// FTDI
+	"0403:6001": {chip: "FT232R", variants: map[string]string{"0200": "FT232AM", "0400": "FT232BM", "0600": "FT232R"}},
+	"0403:6010": {chip: "FT2232", variants: map[string]string{"0500": "FT2232C", "0700": "FT2232H"}, channels: true},
+	"0403:6011": {chip: "FT4232", variants: map[string]string{"0800": "FT4232H"}, channels: true},
+	"0403:6014": {chip: "FT232H", variants: map[string]string{"0900": "FT232H"}},
+	"0403:6015": {chip: "FT-X", variants: map[string]string{"1000": "FT230X/FT231X"}},
+	// WCH
+	"1a86:7523": {chip: "CH340"},
+	"1a86:7522": {chip: "CH340", variants: map[string]string{"": "CH340K"}},
+	"1a86:5523": {chip: "CH341"},
+	"1a86:55d3": {chip: "CH343"},
+	"1a86:55d4": {chip: "CH9102"},
+	// Silicon Labs
+	"10c4:ea60": {chip: "CP210x"},
+	"10c4:ea70": {chip: "CP210x", variants: map[string]string{"": "CP2105"}},
+	"10c4:ea71": {chip: "CP210x", variants: map[string]string{"": "CP2108"}},
+	// Prolific
+	"067b:2303": {chip: "PL2303", variants: map[string]string{"0300": "PL2303HXA", "0400": "PL2303HXD"}},
+	"067b:23a3": {chip: "PL2303", variants: map[string]string{"": "PL2303GC"}},
+	"067b:23b3": {chip: "PL2303", variants: map[string]string{"": "PL2303GB"}},
+	"067b:23c3": {chip: "PL2303", variants: map[string]string{"": "PL2303GT"}},
+	"067b:23d3": {chip: "PL2303", variants: map[string]string{"": "PL2303GL"}},
+	"067b:23e3": {chip: "PL2303", variants: map[string]string{"": "PL2303GE"}},
+	"067b:23f3": {chip: "PL2303", variants: map[string]string{"": "PL2303GS"}},
+}
+
+var cp210xProductRegexp = regexp.MustCompile(`CP21\d\d[A-Z]?`)
+
+// setChipProperties identifies the USB-to-serial bridge chip of the port
+// and sets the "chip" and "chipVariant" properties, along with the
+// "chipChannel" of multi-channel chips and a "counterfeit" warning for the
+// known signatures of counterfeit chips.
+func setChipProperties(props *properties.Map, port *enumerator.PortDetails, dev *usbDevice) {
+	vid, pid := strings.ToLower(port.VID), strings.ToLower(port.PID)
+	bcdDevice := ""
+	if dev != nil {
+		bcdDevice = dev.bcdDevice
+	}
+
+	if vid == "0403" && pid == "0000" {
+		// The FTDI driver bricks the counterfeit FT232R by erasing the PID
+		props.Set("chip", "FT232R")
+		props.Set("counterfeit", "PID erased by the FTDI driver")
+		return
+	}
+
+	bridge, ok := bridgeChips[vid+":"+pid]
+	if !ok {
+		if dev != nil && dev.driver == "cdc_acm" {
+			props.Set("chip", "native-cdc")
+		}
+		return
+	}
+	props.Set("chip", bridge.chip)
+
+	variant, ok := bridge.variants[bcdDevice]
+	if !ok {
+		variant = bridge.variants[""]
+	}
+	if bridge.chip == "CP210x" && variant == "" {
+		variant = cp210xProductRegexp.FindString(port.Product)
+	}
+	if variant != "" {
+		props.Set("chipVariant", variant)
+	}
+
+	if bridge.channels && dev != nil && dev.interfaceNumber >= 0 {
+		props.Set("chipChannel", string(rune('A'+dev.interfaceNumber)))
+	}
+
+	switch {
+	case variant == "FT232R" && port.SerialNumber == "A50285BI":
+		props.Set("counterfeit", "serial number used by counterfeit FT232R chips")
+	case variant == "PL2303HXA":
+		props.Set("counterfeit", "discontinued chip, most devices using it are counterfeit")
+	}
+}
@@ -0,0 +1,140 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arduino/go-properties-orderedmap"
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+)
+
+// CorrelationWindow is the maximum time between the disconnection of a
+// port and the connection of another one for the two ports to be recognized
+// as the same physical board, for example when a board is reset into its
+// bootloader and comes back with a different PID. Zero disables the
+// correlation.
+var CorrelationWindow = 5 * time.Second
+
+// departure is a port that has been disconnected
+type departure struct {
+	port *discovery.Port
+	at   time.Time
+}
+
+// correlate looks for a recently disconnected port belonging to the same
+// physical board of the given port. If found, the departure is returned
+// along with the properties that link the new port to the old one.
+func correlate(port *discovery.Port, departures []*departure) (*departure, *properties.Map) {
+	var match *departure
+	for _, d := range departures {
+		if !sameBoard(d.port, port) {
+			continue
+		}
+		if d.port.Address == port.Address {
+			match = d
+			break
+		}
+		if match == nil || d.at.After(match.at) {
+			match = d
+		}
+	}
+	if match == nil {
+		return nil, nil
+	}
+
+	old := match.port
+	props := properties.NewMap()
+	props.Set("previousAddress", old.Address)
+	if id := old.Properties.Get("physicalId"); id != "" {
+		props.Set("physicalId", id)
+	}
+	if isBootloader(old, port) {
+		props.Set("bootloader", "true")
+	}
+	return match, props
+}
+
+// isBootloader returns true if the port is the bootloader of the board of
+// the correlated old port. For the vendors in bootloaderVendors the PIDs tell
+// which of the two is the bootloader, so that a board plugged in while in
+// its bootloader is not mistaken when its application starts. For the other
+// vendors a board that changes PID is assumed to be entering its bootloader.
+func isBootloader(old, port *discovery.Port) bool {
+	if bootloaderVendors[parseUSBID(port.Properties.Get("vid"))] {
+		return bootloaderPair(old, port) && parseUSBID(port.Properties.Get("pid"))&0x8000 == 0
+	}
+	return old.Properties.Get("pid") != port.Properties.Get("pid") && !old.Properties.GetBoolean("bootloader")
+}
+
+// sameBoard returns true if the two ports may belong to the same physical
+// board: they must be connected to the same USB port, with a compatible
+// serial number, or, if the USB topology is not available, they must have
+// the same serial number.
+func sameBoard(a, b *discovery.Port) bool {
+	if !a.Properties.ContainsKey("vid") || !b.Properties.ContainsKey("vid") {
+		return false
+	}
+	if portKey(a) == portKey(b) {
+		// The same port came back, there is nothing to correlate
+		return false
+	}
+	pathA, pathB := a.Properties.Get("usbPath"), b.Properties.Get("usbPath")
+	if pathA != "" && pathB != "" {
+		if pathA != pathB {
+			return false
+		}
+		// Another board may have been plugged into the same USB port: the
+		// serial number may only change between a known bootloader and its
+		// application, or be missing in one of the two.
+		serialA, serialB := a.Properties.Get("serialNumber"), b.Properties.Get("serialNumber")
+		return serialA == serialB || serialA == "" || serialB == "" || bootloaderPair(a, b)
+	}
+	if a.Properties.GetBoolean("duplicateSerial") || b.Properties.GetBoolean("duplicateSerial") {
+		// The serial number does not identify the board
+		return false
+	}
+	serial := a.Properties.Get("serialNumber")
+	return serial != "" && serial == b.Properties.Get("serialNumber")
+}
+
+// bootloaderVendors are the USB vendors whose bootloaders use the PID of the
+// application with the most significant bit cleared, for example 0x0036 for
+// the bootloader of the Arduino Leonardo and 0x8036 for its sketch.
+var bootloaderVendors = map[uint64]bool{
+	0x2341: true, // Arduino
+	0x2a03: true, // Arduino (arduino.org)
+	0x239a: true, // Adafruit
+}
+
+// bootloaderPair returns true if one of the two ports is the bootloader and
+// the other one the application of the same kind of board
+func bootloaderPair(a, b *discovery.Port) bool {
+	vidA, pidA := parseUSBID(a.Properties.Get("vid")), parseUSBID(a.Properties.Get("pid"))
+	vidB, pidB := parseUSBID(b.Properties.Get("vid")), parseUSBID(b.Properties.Get("pid"))
+	return vidA == vidB && bootloaderVendors[vidA] && pidA^pidB == 0x8000
+}
+
+// parseUSBID parses a VID or PID in the "0x" prefixed hex format of the
+// port properties, it returns 0 if the value is not valid
+func parseUSBID(value string) uint64 {
+	id, _ := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(value), "0x"), 16, 16)
+	return id
+}
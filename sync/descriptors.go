@@ -0,0 +1,64 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/arduino/go-properties-orderedmap"
+)
+
+// setDescriptorProperties adds the properties taken from the USB
+// descriptors of the device and of the interface of the port
+func setDescriptorProperties(props *properties.Map, dev *usbDevice) {
+	if dev == nil {
+		return
+	}
+	if dev.interfaceName != "" {
+		props.Set("interfaceName", dev.interfaceName)
+	}
+	desc := dev.descriptors
+	if desc == nil {
+		return
+	}
+	props.Set("bcdDevice", fmt.Sprintf("0x%04x", desc.BcdDevice))
+
+	config := desc.Configuration(dev.configuration)
+	if config == nil && len(desc.Configurations) > 0 {
+		config = desc.Configurations[0]
+	}
+	if config == nil {
+		return
+	}
+	props.Set("maxPower", fmt.Sprintf("%dmA", config.MaxPower))
+
+	if dev.interfaceNumber < 0 {
+		return
+	}
+	intf := config.Interface(uint8(dev.interfaceNumber), 0)
+	if intf == nil {
+		return
+	}
+	props.Set("interfaceClass", fmt.Sprintf("0x%02x", intf.Class))
+	props.Set("interfaceSubClass", fmt.Sprintf("0x%02x", intf.SubClass))
+	props.Set("interfaceProtocol", fmt.Sprintf("0x%02x", intf.Protocol))
+	if intf.CDC != nil && intf.CDC.HasACM {
+		props.Set("cdcCapabilities", strings.Join(intf.CDC.ACMCapabilityNames(), ","))
+	}
+}
@@ -0,0 +1,70 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/arduino/go-properties-orderedmap"
+	"github.com/arduino/serial-discovery/usbdesc"
+)
+
+func TestSetDescriptorProperties(t *testing.T) {
+	// Arduino MKR WiFi 1010, a CDC-ACM function grouped by an IAD
+	data, _ := hex.DecodeString(strings.Join(strings.Fields(`
+12 01 00 02 ef 02 01 40 41 23 54 80 00 01 01 02 03 01
+09 02 4b 00 02 01 00 80 fa
+08 0b 00 02 02 02 00 00
+09 04 00 00 01 02 02 00 00
+05 24 00 10 01
+05 24 01 01 01
+04 24 02 06
+05 24 06 00 01
+07 05 81 03 10 00 10
+09 04 01 00 02 0a 00 00 00
+07 05 02 02 40 00 00
+07 05 83 02 40 00 00
+`), ""))
+	desc, err := usbdesc.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev := &usbDevice{interfaceNumber: 0, interfaceName: "Arduino MKR WiFi 1010", descriptors: desc, configuration: 1}
+
+	props := properties.NewMap()
+	setDescriptorProperties(props, dev)
+	want := map[string]string{
+		"interfaceName":     "Arduino MKR WiFi 1010",
+		"bcdDevice":         "0x0100",
+		"maxPower":          "500mA",
+		"interfaceClass":    "0x02",
+		"interfaceSubClass": "0x02",
+		"interfaceProtocol": "0x00",
+		"cdcCapabilities":   "line_coding,send_break",
+	}
+	for key, value := range want {
+		if got := props.Get(key); got != value {
+			t.Errorf("%s is %q, want %q", key, got, value)
+		}
+	}
+	if props.Size() != len(want) {
+		t.Errorf("unexpected properties %s", props.Dump())
+	}
+}
@@ -0,0 +1,56 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/arduino/go-properties-orderedmap"
+	"github.com/arduino/serial-discovery/usbdesc"
+)
+
+// DFUDevices enables the discovery of the USB devices in DFU mode (for
+// example the Arduino Giga, Portenta and Opta boards running their
+// bootloader, or the STM32 ROM bootloader): these devices have no serial
+// port but expose a DFU interface, they are reported with the "dfu"
+// protocol and the USB path as address. Supported only on Linux.
+var DFUDevices bool
+
+// setDFUProperties adds the properties of the DFU interface with the given
+// alternate settings: the name of each alternate setting (that usually
+// describes the memory region it gives access to) and the capabilities
+// declared in the DFU functional descriptor
+func setDFUProperties(props *properties.Map, alts []*usbdesc.Interface, names map[uint8]string) {
+	var dfu *usbdesc.DFUFunctional
+	for _, alt := range alts {
+		props.Set("dfuAlt."+strconv.Itoa(int(alt.AlternateSetting)), names[alt.AlternateSetting])
+		if alt.DFU != nil {
+			dfu = alt.DFU
+		}
+	}
+	if dfu == nil {
+		return
+	}
+	if dfu.BcdDFU != 0 {
+		props.Set("dfuVersion", fmt.Sprintf("0x%04x", dfu.BcdDFU))
+	}
+	props.Set("dfuTransferSize", strconv.Itoa(int(dfu.TransferSize)))
+	props.Set("dfuAttributes", strings.Join(dfu.AttributeNames(), ","))
+}
@@ -0,0 +1,153 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	gosync "sync"
+
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+	"github.com/arduino/serial-discovery/usbdesc"
+)
+
+// dfuNames caches the names of the alternate settings read from the DFU
+// devices, by bus and device number, until the device goes away: the
+// devices are queried only once, since a request sent during an upload
+// might disturb it.
+var dfuNames = struct {
+	gosync.Mutex
+	byDevice map[string]map[uint8]string
+}{byDevice: map[string]map[uint8]string{}}
+
+// dfuPorts scans the USB devices in sysfs looking for the ones exposing a
+// DFU mode interface
+func dfuPorts() []*discovery.Port {
+	dfuNames.Lock()
+	defer dfuNames.Unlock()
+	present := map[string]bool{}
+	defer func() {
+		for key := range dfuNames.byDevice {
+			if !present[key] {
+				delete(dfuNames.byDevice, key)
+			}
+		}
+	}()
+
+	res := []*discovery.Port{}
+	for _, dir := range usbDeviceDirs() {
+		intfDir := findDFUInterface(dir)
+		if intfDir == "" {
+			continue
+		}
+		present[readSysfsAttr(dir, "busnum")+"/"+readSysfsAttr(dir, "devnum")] = true
+		number, err := strconv.ParseUint(readSysfsAttr(intfDir, "bInterfaceNumber"), 16, 8)
+		if err != nil {
+			continue
+		}
+
+		props := usbDeviceProperties(dir)
+		setUSBDeviceIDs(props)
+		props.Set("dfuInterface", strconv.Itoa(int(number)))
+		alts := dfuAlternateSettings(dir, intfDir, uint8(number))
+		setDFUProperties(props, alts, dfuAlternateSettingNames(dir, intfDir, alts))
+		name := filepath.Base(dir)
+		res = append(res, &discovery.Port{
+			Address:       name,
+			AddressLabel:  name,
+			Protocol:      "dfu",
+			ProtocolLabel: "USB DFU Device",
+			Properties:    props,
+			HardwareID:    props.Get("serialNumber"),
+		})
+	}
+	return res
+}
+
+// findDFUInterface returns the sysfs directory of the DFU mode interface
+// of the USB device, or an empty string if the device is not in DFU mode.
+// The DFU runtime interfaces, exposed by the devices running their
+// application, are ignored: these devices are reported by their serial port.
+func findDFUInterface(dir string) string {
+	interfaces, _ := filepath.Glob(filepath.Join(dir, filepath.Base(dir)+":*"))
+	for _, intf := range interfaces {
+		if readSysfsAttr(intf, "bInterfaceClass") == "fe" &&
+			readSysfsAttr(intf, "bInterfaceSubClass") == "01" &&
+			readSysfsAttr(intf, "bInterfaceProtocol") == "02" {
+			return intf
+		}
+	}
+	return ""
+}
+
+// dfuAlternateSettings returns the descriptors of the alternate settings of
+// the DFU interface. If the descriptors are not available only the current
+// alternate setting is returned.
+func dfuAlternateSettings(dir, intfDir string, number uint8) []*usbdesc.Interface {
+	if data, err := os.ReadFile(filepath.Join(dir, "descriptors")); err == nil {
+		if desc, err := usbdesc.Parse(data); err == nil {
+			var config *usbdesc.Configuration
+			if n, err := strconv.ParseUint(readSysfsAttr(dir, "bConfigurationValue"), 10, 8); err == nil {
+				config = desc.Configuration(uint8(n))
+			}
+			if config == nil && len(desc.Configurations) > 0 {
+				config = desc.Configurations[0]
+			}
+			if config != nil {
+				if alts := config.AlternateSettings(number); len(alts) > 0 {
+					return alts
+				}
+			}
+		}
+	}
+	alt, err := strconv.ParseUint(readSysfsAttr(intfDir, "bAlternateSetting"), 10, 8)
+	if err != nil {
+		return nil
+	}
+	return []*usbdesc.Interface{{Number: number, AlternateSetting: uint8(alt)}}
+}
+
+// dfuAlternateSettingNames returns the names of the alternate settings of
+// the DFU interface. The names are read from the device the first time it
+// is seen, if it is accessible and allowed by activeUSBProbeFilter,
+// otherwise only the name of the current alternate setting, cached by the
+// kernel, is available. It must be called with dfuNames locked.
+func dfuAlternateSettingNames(dir, intfDir string, alts []*usbdesc.Interface) map[uint8]string {
+	key := readSysfsAttr(dir, "busnum") + "/" + readSysfsAttr(dir, "devnum")
+	if names, ok := dfuNames.byDevice[key]; ok {
+		return names
+	}
+	names := map[uint8]string{}
+	if activeUSBProbeFilter(readSysfsAttr(dir, "idVendor"), readSysfsAttr(dir, "idProduct")) {
+		if r, err := openUSBStrings(dir); err == nil {
+			defer r.Close()
+			for _, alt := range alts {
+				if name, err := r.Get(alt.IInterface); err == nil {
+					names[alt.AlternateSetting] = name
+				}
+			}
+			dfuNames.byDevice[key] = names
+			return names
+		}
+	}
+	if alt, err := strconv.ParseUint(readSysfsAttr(intfDir, "bAlternateSetting"), 10, 8); err == nil {
+		names[uint8(alt)] = readSysfsAttr(intfDir, "interface")
+	}
+	return names
+}
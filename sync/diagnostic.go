@@ -0,0 +1,46 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	"time"
+)
+
+// Diagnostic is a structured report of a non-fatal condition detected while
+// running the discovery, for example an enumeration that is being retried.
+type Diagnostic struct {
+	Time    time.Time `json:"time"`
+	Event   string    `json:"event"`
+	Message string    `json:"message"`
+	Port    string    `json:"port,omitempty"`
+	Attempt int       `json:"attempt,omitempty"`
+	DelayMs int64     `json:"delayMs,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// DiagnosticCB, if not nil, is called for each Diagnostic produced by the
+// sync backends. It may be called concurrently from different goroutines.
+var DiagnosticCB func(d *Diagnostic)
+
+func diagnostic(d *Diagnostic) {
+	if DiagnosticCB == nil {
+		return
+	}
+	d.Time = time.Now()
+	DiagnosticCB(d)
+}
@@ -0,0 +1,73 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+)
+
+// markDuplicateSerials looks for USB ports of different devices reporting
+// the same serial number, as it often happens with cheap clones. The serial
+// number of these ports cannot be used as an identifier, so they are given
+// a HardwareID disambiguated with their device ID and a "duplicateSerial"
+// property. Without the USB topology the device ID is made of VID, PID and
+// serial number, so only devices with a different VID or PID can be told
+// apart: the interfaces of the same device are never reported as duplicates.
+func markDuplicateSerials(ports []*discovery.Port) {
+	bySerial := map[string][]*discovery.Port{}
+	for _, port := range ports {
+		if !port.Properties.ContainsKey("vid") {
+			continue
+		}
+		if serial := port.Properties.Get("serialNumber"); serial != "" {
+			bySerial[serial] = append(bySerial[serial], port)
+		}
+	}
+
+	for serial, group := range bySerial {
+		// Interfaces of the same device share the serial number legitimately
+		devices := map[string]bool{}
+		for _, port := range group {
+			devices[deviceLocation(port)] = true
+		}
+		if len(devices) < 2 {
+			continue
+		}
+
+		for _, port := range group {
+			id := serial + "@" + deviceLocation(port)
+			port.Properties.Set("duplicateSerial", "true")
+			if port.Properties.Get("hardwareIdSource") == "serial" {
+				port.HardwareID = id
+			}
+			if port.Properties.Get("physicalId") == serial {
+				port.Properties.Set("physicalId", id)
+			}
+		}
+	}
+}
+
+// deviceLocation returns the device ID of the port, that is the position of
+// its USB device in the USB topology or, if the topology is not available,
+// its VID, PID and serial number
+func deviceLocation(port *discovery.Port) string {
+	if id := port.Properties.Get("deviceId"); id != "" {
+		return id
+	}
+	return port.Address
+}
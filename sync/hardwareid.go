@@ -0,0 +1,107 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+	"go.bug.st/serial/enumerator"
+)
+
+// HardwareIDMethods is the ordered list of methods tried to compute the
+// HardwareID of a USB port, the first one giving a result is used:
+// - "serial": the USB serial number
+// - "topology": the position of the device in the USB topology, along with
+// its VID and PID. It is stable as long as the device is connected to the
+// same USB port.
+// - "descriptor": a hash of the USB descriptor data (VID, PID, manufacturer
+// and product). It is stable but it is not unique among identical devices,
+// so it is dropped when identical devices are connected at the same time.
+var HardwareIDMethods = []string{"serial", "topology", "descriptor"}
+
+// ValidateHardwareIDMethods returns an error if one of the given methods is
+// not supported
+func ValidateHardwareIDMethods(methods []string) error {
+	for _, method := range methods {
+		switch method {
+		case "serial", "topology", "descriptor":
+		default:
+			return fmt.Errorf("invalid hardware id method: %s", method)
+		}
+	}
+	return nil
+}
+
+// computeHardwareID computes the HardwareID of a USB port using HardwareIDMethods,
+// the method that produced the ID is returned as well.
+func computeHardwareID(port *enumerator.PortDetails, dev *usbDevice) (id string, method string) {
+	for _, method := range HardwareIDMethods {
+		switch method {
+		case "serial":
+			if port.SerialNumber != "" {
+				return port.SerialNumber, method
+			}
+		case "topology":
+			if dev != nil {
+				return strings.ToLower(port.VID+":"+port.PID) + "@" + dev.path, method
+			}
+		case "descriptor":
+			data := strings.Join([]string{port.VID, port.PID, port.Manufacturer, port.Product}, "\x00")
+			hash := sha256.Sum256([]byte(strings.ToLower(data)))
+			return hex.EncodeToString(hash[:8]), method
+		}
+	}
+	return "", ""
+}
+
+// clearAmbiguousHardwareIDs clears the HardwareID computed with the
+// "descriptor" method when it is shared by different devices, for example
+// two boards with the same serial-less bridge chip: such an ID would not
+// identify the hardware. The ports of the same device are recognized by
+// their USB topology, without it every port is considered a different device.
+func clearAmbiguousHardwareIDs(ports []*discovery.Port) {
+	byID := map[string][]*discovery.Port{}
+	for _, port := range ports {
+		if port.Properties.Get("hardwareIdSource") == "descriptor" {
+			byID[port.HardwareID] = append(byID[port.HardwareID], port)
+		}
+	}
+
+	for _, group := range byID {
+		devices := map[string]bool{}
+		for _, port := range group {
+			if path := port.Properties.Get("usbPath"); path != "" {
+				devices[path] = true
+			} else {
+				devices[port.Address] = true
+			}
+		}
+		if len(devices) < 2 {
+			continue
+		}
+
+		for _, port := range group {
+			port.HardwareID = ""
+			port.Properties.Remove("hardwareIdSource")
+		}
+	}
+}
@@ -0,0 +1,34 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	"context"
+
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+)
+
+// List enumerates the ports once and returns them as they would be reported
+// by the initial "add" events of a sync session.
+func List(ctx context.Context) ([]*discovery.Port, error) {
+	details, err := getPortsList(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return allowedPorts(collectPorts(details)), nil
+}
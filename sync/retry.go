@@ -0,0 +1,83 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	"context"
+	"time"
+
+	"go.bug.st/serial/enumerator"
+)
+
+// RetryPolicy defines how a failed port enumeration is retried.
+type RetryPolicy struct {
+	// Attempts is the maximum number of retries after the first failure
+	Attempts int
+	// Delay is the wait time before the first retry, it is doubled on each
+	// subsequent retry
+	Delay time.Duration
+	// MaxDelay is the upper bound of the wait time between two retries
+	MaxDelay time.Duration
+}
+
+// EnumerationRetryPolicy is the RetryPolicy used by all the backends when
+// a port enumeration fails, for example because a device is still being
+// initialized by the OS.
+var EnumerationRetryPolicy = RetryPolicy{
+	Attempts: 5,
+	Delay:    50 * time.Millisecond,
+	MaxDelay: time.Second,
+}
+
+// getPortsList enumerates the serial ports, retrying with backoff as
+// defined by EnumerationRetryPolicy. If ready is not nil the enumeration is
+// retried also while ready returns false: this gives the OS the time to
+// complete the setup of a just connected device. When the retries are
+// exhausted the last successful enumeration is returned anyway.
+func getPortsList(ctx context.Context, ready func([]*enumerator.PortDetails) bool) ([]*enumerator.PortDetails, error) {
+	policy := EnumerationRetryPolicy
+	delay := policy.Delay
+	for attempt := 1; ; attempt++ {
+		ports, err := enumerator.GetDetailedPortsList(activeUSBProbeFilter)
+		if err == nil && (ready == nil || ready(ports)) {
+			return ports, nil
+		}
+		if attempt > policy.Attempts {
+			return ports, err
+		}
+
+		d := &Diagnostic{
+			Event:   "enumeration_retry",
+			Message: "port list not ready, retrying enumeration",
+			Attempt: attempt,
+			DelayMs: delay.Milliseconds(),
+		}
+		if err != nil {
+			d.Message = "port enumeration failed, retrying"
+			d.Error = err.Error()
+		}
+		diagnostic(d)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, policy.MaxDelay)
+	}
+}
@@ -0,0 +1,42 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+	"github.com/arduino/serial-discovery/rules"
+)
+
+// Rules, if not nil, are the include/exclude rules applied to the ports
+// before they are reported: the ports not allowed by the rules are never
+// sent to the client.
+var Rules *rules.RuleSet
+
+// allowedPorts returns the ports of the list allowed by the Rules
+func allowedPorts(list []*discovery.Port) []*discovery.Port {
+	if Rules == nil {
+		return list
+	}
+	res := make([]*discovery.Port, 0, len(list))
+	for _, port := range list {
+		if Rules.Allow(port) {
+			res = append(res, port)
+		}
+	}
+	return res
+}
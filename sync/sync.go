@@ -24,9 +24,9 @@
 // protocol format.
 //
 // The main function in this package is `processUpdates`, which takes in two lists
-// of serial port details and an event callback function. It compares the two lists
-// and sends 'add' and 'remove' events based on the differences. The `portListHas`
-// function is used to check if a port is contained in a list, and the `toDiscoveryPort`
+// of ports and an event callback function. It compares the two lists and sends
+// 'add', 'remove' and 'change' events based on the differences. The `portKey`
+// function is used to match the ports of the two lists, and the `toDiscoveryPort`
 // function is used to convert port details to the discovery protocol format.
 package sync
 
@@ -37,45 +37,59 @@ import (
 )
 
 // nolint
-// processUpdates sends 'add' and 'remove' events by comparing two ports enumeration
-// made at different times:
+// processUpdates sends 'add', 'remove' and 'change' events by comparing two ports
+// enumeration made at different times:
 // - ports present in the new list but not in the old list are reported as 'added'
 // - ports present in the old list but not in the new list are reported as 'removed'
-func processUpdates(old, new []*enumerator.PortDetails, eventCB discovery.EventCallback) {
+// - ports present in both lists but with different attributes are reported as 'changed'
+func processUpdates(old, new []*discovery.Port, eventCB discovery.EventCallback) {
+	oldPorts := portsByKey(old)
+	newPorts := portsByKey(new)
+
 	for _, oldPort := range old {
-		if !portListHas(new, oldPort) {
-			eventCB("remove", &discovery.Port{
-				Address:  oldPort.Name,
-				Protocol: "serial",
-			})
+		if _, ok := newPorts[portKey(oldPort)]; !ok {
+			// Report the port as it was last announced, the client may not
+			// have cached its metadata
+			eventCB("remove", oldPort)
 		}
 	}
 
 	for _, newPort := range new {
-		if !portListHas(old, newPort) {
-			eventCB("add", toDiscoveryPort(newPort))
+		if oldPort, ok := oldPorts[portKey(newPort)]; !ok {
+			eventCB("add", newPort)
+		} else if !portEquals(oldPort, newPort) {
+			eventCB("change", newPort)
 		}
 	}
 }
 
-// nolint
-// portListHas checks if port is contained in list. The port metadata are
-// compared in particular the port address, and vid/pid if the port is a usb port.
-func portListHas(list []*enumerator.PortDetails, port *enumerator.PortDetails) bool {
-	for _, p := range list {
-		if port.Name == p.Name && port.IsUSB == p.IsUSB {
-			if p.IsUSB &&
-				port.VID == p.VID &&
-				port.PID == p.PID &&
-				port.SerialNumber == p.SerialNumber {
-				return true
-			}
-			if !p.IsUSB {
-				return true
-			}
-		}
+// portKey returns a string that identifies the port: two ports are the same
+// port if they have the same address and protocol and, for USB ports, the
+// same VID, PID and serial number.
+func portKey(port *discovery.Port) string {
+	key := port.Protocol + "\x00" + port.Address
+	if vid, ok := port.Properties.GetOk("vid"); ok {
+		key += "\x00usb\x00" + vid + "\x00" + port.Properties.Get("pid") + "\x00" + port.Properties.Get("serialNumber")
 	}
-	return false
+	return key
+}
+
+func portsByKey(list []*discovery.Port) map[string]*discovery.Port {
+	res := make(map[string]*discovery.Port, len(list))
+	for _, port := range list {
+		res[portKey(port)] = port
+	}
+	return res
+}
+
+// portEquals returns true if the two ports have the same attributes
+func portEquals(a, b *discovery.Port) bool {
+	return a.Address == b.Address &&
+		a.AddressLabel == b.AddressLabel &&
+		a.Protocol == b.Protocol &&
+		a.ProtocolLabel == b.ProtocolLabel &&
+		a.HardwareID == b.HardwareID &&
+		a.Properties.Equals(b.Properties)
 }
 
 func toDiscoveryPort(port *enumerator.PortDetails) *discovery.Port {
@@ -92,8 +106,31 @@ func toDiscoveryPort(port *enumerator.PortDetails) *discovery.Port {
 			props.Set("manufacturer", port.Manufacturer)
 			props.Set("product", port.Product)
 		}
+		setUSBIDsProperties(props, port.VID, port.PID)
+		setBoardProperties(props, port.VID, port.PID)
+		physicalID := port.SerialNumber
+		dev := lookupUSBDevice(port.Name)
+		if dev != nil {
+			props.Set("usbPath", dev.path)
+			if physicalID == "" {
+				physicalID = "usb:" + dev.path
+			}
+		}
+		if physicalID != "" {
+			props.Set("physicalId", physicalID)
+		}
+		if byID := lookupByID(port.Name); byID != "" {
+			props.Set("byId", byID)
+		}
+		setDeviceProperties(props, port, dev)
+		setChipProperties(props, port, dev)
+		setDescriptorProperties(props, dev)
 
-		hardwareID = port.SerialNumber
+		var method string
+		hardwareID, method = computeHardwareID(port, dev)
+		if method != "" {
+			props.Set("hardwareIdSource", method)
+		}
 	}
 	res := &discovery.Port{
 		Address:       port.Name,
@@ -106,6 +143,16 @@ func toDiscoveryPort(port *enumerator.PortDetails) *discovery.Port {
 	return res
 }
 
+func toDiscoveryPorts(details []*enumerator.PortDetails) []*discovery.Port {
+	res := make([]*discovery.Port, 0, len(details))
+	for _, port := range details {
+		res = append(res, toDiscoveryPort(port))
+	}
+	clearAmbiguousHardwareIDs(res)
+	markDuplicateSerials(res)
+	return res
+}
+
 var activeUSBProbeFilter = func(vid, _ /*pid*/ string) bool {
 	// Only perform active probing on Arduino devices, to avoid issues with some devices that don't support it.
 	return vid == "2341"
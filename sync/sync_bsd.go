@@ -23,9 +23,9 @@ import (
 	"context"
 	"fmt"
 	"syscall"
+	"time"
 
 	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
-	"go.bug.st/serial/enumerator"
 )
 
 // Start the sync process, successful events will be passed to eventCB, errors to errorCB.
@@ -59,14 +59,16 @@ func Start(ctx context.Context, eventCB discovery.EventCallback, errorCB discove
 		defer syscall.Close(kq)
 
 		// Output initial port state: get the current port list to send as initial "add" events
-		current, err := enumerator.GetDetailedPortsList(activeUSBProbeFilter)
+		current, err := getPortsList(ctx, nil)
 		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
 			errorCB(err.Error())
 			return
 		}
-		for _, port := range current {
-			eventCB("add", toDiscoveryPort(port))
-		}
+		tracker := newPortTracker(ctx, eventCB)
+		tracker.init(current)
 
 		// wait for events
 		events := make([]syscall.Kevent_t, 10)
@@ -90,19 +92,14 @@ func Start(ctx context.Context, eventCB discovery.EventCallback, errorCB discove
 				continue
 			}
 
-			// if there is an event retry up to 5 times
-			var enumeratorErr error
-			for retries := 0; retries < 5; retries++ {
-				updates, err := enumerator.GetDetailedPortsList(activeUSBProbeFilter)
-				if err != nil {
-					enumeratorErr = err
-					break
+			// The /dev entries may be created before the enumerator is able
+			// to report the new ports: diff the port list again a few
+			// times, backing off as defined by EnumerationRetryPolicy.
+			if err := rediff(ctx, tracker); err != nil {
+				if ctx.Err() != nil {
+					return
 				}
-				processUpdates(current, updates, eventCB)
-				current = updates
-			}
-			if enumeratorErr != nil {
-				errorCB(fmt.Sprintf("Error enumerating serial ports: %s", enumeratorErr))
+				errorCB(fmt.Sprintf("Error enumerating serial ports: %s", err))
 				break
 			}
 		}
@@ -112,3 +109,28 @@ func Start(ctx context.Context, eventCB discovery.EventCallback, errorCB discove
 
 	return nil
 }
+
+// rediff updates the tracker with the port list, then enumerates and diffs
+// the ports again for the number of attempts of EnumerationRetryPolicy,
+// waiting with backoff between two enumerations.
+func rediff(ctx context.Context, tracker *portTracker) error {
+	policy := EnumerationRetryPolicy
+	delay := policy.Delay
+	for attempt := 0; ; attempt++ {
+		updates, err := getPortsList(ctx, nil)
+		if err != nil {
+			return err
+		}
+		tracker.update(updates)
+		if attempt >= policy.Attempts {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, policy.MaxDelay)
+	}
+}
@@ -21,6 +21,9 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
+	gosync "sync"
+	"time"
 
 	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
 	"github.com/s-urbaniak/uevent"
@@ -31,13 +34,13 @@ import (
 // Returns error if sync process can't be started.
 func Start(ctx context.Context, eventCB discovery.EventCallback, errorCB discovery.ErrorCallback) error {
 	// Get the current port list to send as initial "add" events
-	current, err := enumerator.GetDetailedPortsList(activeUSBProbeFilter)
+	current, err := getPortsList(ctx, nil)
 	if err != nil {
 		return err
 	}
 
 	// Start sync reader from udev
-	syncReader, err := uevent.NewReader()
+	syncReader, err := newUeventReader()
 	if err != nil {
 		return err
 	}
@@ -50,11 +53,41 @@ func Start(ctx context.Context, eventCB discovery.EventCallback, errorCB discove
 		}
 	}()
 
+	tracker := newPortTracker(ctx, eventCB)
+
+	// refresh enumerates the ports and updates the tracker, the updates
+	// coming from the uevents and from the mount table are serialized so
+	// that an older enumeration never overrides a newer one. It returns
+	// false if the sync process has been stopped.
+	var refreshLock gosync.Mutex
+	refresh := func(event, changedPort string, ready func([]*enumerator.PortDetails) bool) bool {
+		refreshLock.Lock()
+		defer refreshLock.Unlock()
+		portList, err := getPortsList(ctx, ready)
+		if err != nil {
+			if ctx.Err() != nil {
+				return false
+			}
+			diagnostic(&Diagnostic{
+				Event:   "enumeration_failed",
+				Message: "port enumeration failed, " + event + " event lost",
+				Port:    changedPort,
+				Error:   err.Error(),
+			})
+			return true
+		}
+		tracker.update(portList)
+		return true
+	}
+
 	// Run synchronous event emitter
 	go func() {
 		// Output initial port state
-		for _, port := range current {
-			eventCB("add", toDiscoveryPort(port))
+		tracker.init(current)
+
+		if UF2Drives {
+			// Mounting a volume generates no uevent, watch the mount table
+			go watchMounts(ctx, func() { refresh("mount", "", nil) })
 		}
 
 		dec := uevent.NewDecoder(syncReader)
@@ -68,30 +101,76 @@ func Start(ctx context.Context, eventCB discovery.EventCallback, errorCB discove
 				errorCB(fmt.Sprintf("Error decoding serial event: %s", err))
 				return
 			}
-			if evt.Subsystem != "tty" {
+			if !watchSubsystem(evt.Subsystem) {
+				continue
+			}
+			if evt.Action != "add" && evt.Action != "remove" && evt.Action != "change" &&
+				evt.Action != "bind" && evt.Action != "unbind" {
 				continue
 			}
 			changedPort := "/dev/" + evt.Vars["DEVNAME"]
-			if evt.Action == "add" {
-				portList, err := enumerator.GetDetailedPortsList(activeUSBProbeFilter)
-				if err != nil {
-					continue
-				}
-				for _, port := range portList {
-					if port.IsUSB && port.Name == changedPort {
-						eventCB("add", toDiscoveryPort(port))
-						break
-					}
+			var ready func([]*enumerator.PortDetails) bool
+			if evt.Subsystem == "tty" && evt.Action == "add" && isUSBDevpath(evt.Devpath) {
+				// The tty may be announced before the enumerator is able to
+				// report its USB details: retry until they show up.
+				ready = func(ports []*enumerator.PortDetails) bool {
+					return findUSBPort(ports, changedPort) != nil
 				}
 			}
-			if evt.Action == "remove" {
-				eventCB("remove", &discovery.Port{
-					Address:  changedPort,
-					Protocol: "serial",
-				})
+			if !refresh(evt.Action, changedPort, ready) {
+				return
+			}
+			if ready != nil && udevRunning() && lookupByID(changedPort) == "" {
+				// udev creates the persistent name of the port after the
+				// tty is announced, it is reported with a "change" event
+				go watchByID(ctx, changedPort, func() { refresh("by-id", changedPort, nil) })
 			}
 		}
 	}()
 
 	return nil
 }
+
+// byIDTimeout is the maximum time waited for udev to create the link to a
+// new port in /dev/serial/by-id. It does not depend on the enumeration retry
+// policy: udev may be slow on a busy system even if the enumeration is not.
+const byIDTimeout = 5 * time.Second
+
+// watchByID polls, with a delay doubling from 50ms up to 500ms, until udev
+// creates the link to the given port in /dev/serial/by-id, then it calls
+// refresh to update the "byId" property of the port. It gives up after
+// byIDTimeout.
+func watchByID(ctx context.Context, portName string, refresh func()) {
+	deadline := time.Now().Add(byIDTimeout)
+	delay := 50 * time.Millisecond
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if lookupByID(portName) != "" {
+			refresh()
+			return
+		}
+		if time.Now().After(deadline) {
+			return
+		}
+		delay = min(delay*2, 500*time.Millisecond)
+	}
+}
+
+// isUSBDevpath returns true if the sysfs path of a device is below a USB
+// host controller, that is the device is connected through USB
+func isUSBDevpath(devpath string) bool {
+	return strings.Contains(devpath, "/usb")
+}
+
+func findUSBPort(list []*enumerator.PortDetails, name string) *enumerator.PortDetails {
+	for _, port := range list {
+		if port.IsUSB && port.Name == name {
+			return port
+		}
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/arduino/go-properties-orderedmap"
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+)
+
+func serialPort(address string) *discovery.Port {
+	return &discovery.Port{Address: address, Protocol: "serial", Properties: properties.NewMap()}
+}
+
+func usbPort(address, vid, pid, serialNumber string) *discovery.Port {
+	port := serialPort(address)
+	port.Properties.Set("vid", vid)
+	port.Properties.Set("pid", pid)
+	port.Properties.Set("serialNumber", serialNumber)
+	port.HardwareID = serialNumber
+	return port
+}
+
+func withProperty(port *discovery.Port, key, value string) *discovery.Port {
+	res := *port
+	res.Properties = port.Properties.Clone()
+	res.Properties.Set(key, value)
+	return &res
+}
+
+func TestProcessUpdates(t *testing.T) {
+	uno := usbPort("/dev/ttyACM0", "0x2341", "0x0043", "A1")
+	tests := []struct {
+		name     string
+		old, new []*discovery.Port
+		events   []string
+	}{
+		{
+			name:   "no changes",
+			old:    []*discovery.Port{serialPort("/dev/ttyS0"), uno},
+			new:    []*discovery.Port{serialPort("/dev/ttyS0"), uno},
+			events: []string{},
+		},
+		{
+			name:   "add non-USB port",
+			old:    []*discovery.Port{},
+			new:    []*discovery.Port{serialPort("/dev/ttyS0")},
+			events: []string{"add /dev/ttyS0"},
+		},
+		{
+			name:   "remove non-USB port",
+			old:    []*discovery.Port{serialPort("/dev/ttyS0")},
+			new:    []*discovery.Port{},
+			events: []string{"remove /dev/ttyS0"},
+		},
+		{
+			name:   "add USB port",
+			old:    []*discovery.Port{serialPort("/dev/ttyS0")},
+			new:    []*discovery.Port{serialPort("/dev/ttyS0"), uno},
+			events: []string{"add /dev/ttyACM0"},
+		},
+		{
+			name:   "remove USB port",
+			old:    []*discovery.Port{uno},
+			new:    []*discovery.Port{},
+			events: []string{"remove /dev/ttyACM0"},
+		},
+		{
+			name:   "same address, only the serial number differs",
+			old:    []*discovery.Port{uno},
+			new:    []*discovery.Port{usbPort("/dev/ttyACM0", "0x2341", "0x0043", "B2")},
+			events: []string{"remove /dev/ttyACM0", "add /dev/ttyACM0"},
+		},
+		{
+			name:   "same address, different VID and PID",
+			old:    []*discovery.Port{uno},
+			new:    []*discovery.Port{usbPort("/dev/ttyACM0", "0x1a86", "0x7523", "A1")},
+			events: []string{"remove /dev/ttyACM0", "add /dev/ttyACM0"},
+		},
+		{
+			name:   "same address, USB port replaced by a non-USB port",
+			old:    []*discovery.Port{uno},
+			new:    []*discovery.Port{serialPort("/dev/ttyACM0")},
+			events: []string{"remove /dev/ttyACM0", "add /dev/ttyACM0"},
+		},
+		{
+			name:   "same USB port with a changed property",
+			old:    []*discovery.Port{uno},
+			new:    []*discovery.Port{withProperty(uno, "boardName", "Arduino Uno")},
+			events: []string{"change /dev/ttyACM0"},
+		},
+		{
+			name:   "same non-USB port with a changed label",
+			old:    []*discovery.Port{serialPort("/dev/ttyS0")},
+			new:    []*discovery.Port{{Address: "/dev/ttyS0", AddressLabel: "COM1", Protocol: "serial", Properties: properties.NewMap()}},
+			events: []string{"change /dev/ttyS0"},
+		},
+		{
+			name:   "USB ports without serial number",
+			old:    []*discovery.Port{usbPort("/dev/ttyUSB0", "0x1a86", "0x7523", "")},
+			new:    []*discovery.Port{usbPort("/dev/ttyUSB0", "0x1a86", "0x7523", ""), usbPort("/dev/ttyUSB1", "0x1a86", "0x7523", "")},
+			events: []string{"add /dev/ttyUSB1"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			events := []string{}
+			processUpdates(test.old, test.new, func(event string, port *discovery.Port) {
+				events = append(events, event+" "+port.Address)
+			})
+			if !slices.Equal(events, test.events) {
+				t.Errorf("got events %q, want %q", events, test.events)
+			}
+		})
+	}
+}
+
+func TestProcessUpdatesRemoveMetadata(t *testing.T) {
+	uno := withProperty(usbPort("/dev/ttyACM0", "0x2341", "0x0043", "A1"), "boardName", "Arduino Uno")
+	processUpdates([]*discovery.Port{uno}, []*discovery.Port{}, func(event string, port *discovery.Port) {
+		if port != uno {
+			t.Errorf("%s event sent with %+v, want the last announced port", event, port)
+		}
+	})
+}
+
+func benchmarkPorts(n int) []*discovery.Port {
+	res := make([]*discovery.Port, 0, n)
+	for i := range n {
+		res = append(res, usbPort(fmt.Sprintf("/dev/ttyUSB%d", i), "0x0403", "0x6001", fmt.Sprintf("FT%06d", i)))
+	}
+	return res
+}
+
+func BenchmarkProcessUpdates(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("ports=%d", n), func(b *testing.B) {
+			old := benchmarkPorts(n)
+			// One port removed, one added and one changed
+			new := slices.Clone(old[1:])
+			new[0] = withProperty(new[0], "boardName", "changed")
+			new = append(new, usbPort("/dev/ttyUSBnew", "0x0403", "0x6001", "FTnew"))
+			for b.Loop() {
+				processUpdates(old, new, func(string, *discovery.Port) {})
+			}
+		})
+	}
+}
+
+func TestCorrelateBootloader(t *testing.T) {
+	tests := []struct {
+		name       string
+		old, new   *discovery.Port
+		bootloader bool
+	}{
+		{
+			name:       "known vendor, sketch reset into the bootloader",
+			old:        usbPort("/dev/ttyACM0", "0x2341", "0x8036", "A1"),
+			new:        usbPort("/dev/ttyACM1", "0x2341", "0x0036", "A1"),
+			bootloader: true,
+		},
+		{
+			name: "known vendor, bootloader starting the sketch",
+			old:  usbPort("/dev/ttyACM0", "0x2341", "0x0036", "A1"),
+			new:  usbPort("/dev/ttyACM1", "0x2341", "0x8036", "A1"),
+		},
+		{
+			name: "known vendor, unrelated PIDs",
+			old:  usbPort("/dev/ttyACM0", "0x2341", "0x0043", "A1"),
+			new:  usbPort("/dev/ttyACM1", "0x2341", "0x0036", "A1"),
+		},
+		{
+			name:       "other vendor, PID changed",
+			old:        usbPort("/dev/ttyACM0", "0x2e8a", "0x000a", "A1"),
+			new:        usbPort("/dev/ttyACM1", "0x2e8a", "0x0003", "A1"),
+			bootloader: true,
+		},
+		{
+			name: "other vendor, back from the bootloader",
+			old:  withProperty(usbPort("/dev/ttyACM0", "0x2e8a", "0x0003", "A1"), "bootloader", "true"),
+			new:  usbPort("/dev/ttyACM1", "0x2e8a", "0x000a", "A1"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d, props := correlate(test.new, []*departure{{port: test.old}})
+			if d == nil {
+				t.Fatal("ports not correlated")
+			}
+			if got := props.GetBoolean("bootloader"); got != test.bootloader {
+				t.Errorf("bootloader is %v, want %v", got, test.bootloader)
+			}
+		})
+	}
+}
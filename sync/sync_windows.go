@@ -26,7 +26,6 @@ import (
 	"unsafe"
 
 	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
-	"go.bug.st/serial/enumerator"
 )
 
 //go:generate go run golang.org/x/sys/windows/mkwinsyscall -output zsyscall_windows.go sync_windows.go
@@ -114,14 +113,16 @@ func Start(ctx context.Context, eventCB discovery.EventCallback, errorCB discove
 	}
 
 	go func() {
-		current, err := enumerator.GetDetailedPortsList(activeUSBProbeFilter)
+		current, err := getPortsList(ctx, nil)
 		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
 			errorCB(fmt.Sprintf("Error enumerating serial ports: %s", err))
 			return
 		}
-		for _, port := range current {
-			eventCB("add", toDiscoveryPort(port))
-		}
+		tracker := newPortTracker(ctx, eventCB)
+		tracker.init(current)
 
 		for {
 			select {
@@ -131,21 +132,25 @@ func Start(ctx context.Context, eventCB discovery.EventCallback, errorCB discove
 				if !ev {
 					return
 				}
+			case <-ctx.Done():
+				return
 			case <-time.After(time.Millisecond * 500):
 				// Use a small timeout instead of default case to avoid high CPU consumption
 			}
-			updates, err := enumerator.GetDetailedPortsList(activeUSBProbeFilter)
+			updates, err := getPortsList(ctx, nil)
 			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
 				errorCB(fmt.Sprintf("Error enumerating serial ports: %s", err))
 				return
 			}
-			processUpdates(current, updates, eventCB)
-			current = updates
+			tracker.update(updates)
 		}
 	}()
 
 	// Context used to stop the goroutine that consume the window messages
-	ctx, cancel := context.WithCancel(context.Background())
+	msgCtx, cancel := context.WithCancel(context.Background())
 
 	go func() {
 		// Lock this goroutine to the same OS thread for its whole execution,
@@ -183,12 +188,12 @@ func Start(ctx context.Context, eventCB discovery.EventCallback, errorCB discove
 		// this goroutine in here and not outside the one that handles
 		// creation and destruction of the window used to receive notifications
 		go func() {
-			if err := consumeMessages(ctx, windowHandle); err != nil {
+			if err := consumeMessages(msgCtx, windowHandle); err != nil {
 				errorCB(err.Error())
 			}
 		}()
 
-		<-ctx.Done()
+		<-msgCtx.Done()
 	}()
 	return nil
 }
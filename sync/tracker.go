@@ -0,0 +1,216 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	"context"
+	"slices"
+	gosync "sync"
+	"time"
+
+	"github.com/arduino/go-properties-orderedmap"
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+	"go.bug.st/serial/enumerator"
+)
+
+// StableAfter is the time a new port must stay connected before being
+// announced with an "add" event. Ports that disappear before this time are
+// never reported. Zero disables the filter.
+var StableAfter time.Duration
+
+// RemoveGrace is the time a disconnected port is still considered present:
+// if the port comes back within this time the removal is not reported.
+// Zero disables the filter.
+var RemoveGrace time.Duration
+
+// observation keeps the state of a port reported by the enumerator
+type observation struct {
+	port      *discovery.Port
+	seenAt    time.Time
+	lostAt    time.Time
+	announced bool
+	// inherited are the properties taken from a correlated port
+	inherited *properties.Map
+}
+
+// portTracker collects the results of the enumerations, filters out the
+// ports that are not stable yet and reports the changes to the client.
+type portTracker struct {
+	ctx          context.Context
+	eventCB      discovery.EventCallback
+	lock         gosync.Mutex
+	observations []*observation
+	index        map[string]*observation
+	stable       []*discovery.Port
+	departures   []*departure
+	timer        *time.Timer
+}
+
+func newPortTracker(ctx context.Context, eventCB discovery.EventCallback) *portTracker {
+	return &portTracker{
+		ctx:     ctx,
+		eventCB: eventCB,
+		index:   map[string]*observation{},
+	}
+}
+
+// init announces the ports available when the discovery is started, they
+// are considered stable since they were already connected.
+func (t *portTracker) init(details []*enumerator.PortDetails) {
+	t.initPorts(collectPorts(details), time.Now())
+}
+
+func (t *portTracker) initPorts(ports []*discovery.Port, now time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for _, port := range ports {
+		t.add(&observation{port: port, announced: true})
+	}
+	t.evaluate(now)
+}
+
+// update records the result of a new enumeration
+func (t *portTracker) update(details []*enumerator.PortDetails) {
+	t.updatePorts(collectPorts(details), time.Now())
+}
+
+func (t *portTracker) updatePorts(ports []*discovery.Port, now time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	keys := portsByKey(ports)
+	for _, obs := range t.observations {
+		if _, ok := keys[portKey(obs.port)]; obs.lostAt.IsZero() && !ok {
+			obs.lostAt = now
+			if obs.announced && CorrelationWindow > 0 {
+				t.departures = append(t.departures, &departure{port: obs.port, at: now})
+			}
+		}
+	}
+	for _, port := range ports {
+		if obs, ok := t.index[portKey(port)]; ok {
+			if !obs.lostAt.IsZero() {
+				t.departures = slices.DeleteFunc(t.departures, func(d *departure) bool { return d.port == obs.port })
+			}
+			if obs.inherited != nil {
+				port.Properties.Merge(obs.inherited)
+			}
+			obs.port = port
+			obs.lostAt = time.Time{}
+		} else {
+			// A different port has taken the address of a port in its
+			// grace period: the clients identify the ports by address, so
+			// the old one must be removed before the new one is added
+			for _, old := range t.observations {
+				if !old.lostAt.IsZero() && old.port.Address == port.Address && old.port.Protocol == port.Protocol {
+					old.lostAt = now.Add(-RemoveGrace)
+				}
+			}
+			t.add(&observation{port: port, seenAt: now})
+		}
+	}
+	t.evaluate(now)
+}
+
+func (t *portTracker) add(obs *observation) {
+	t.observations = append(t.observations, obs)
+	t.index[portKey(obs.port)] = obs
+}
+
+// evaluate computes the set of stable ports, sends the differences with the
+// previous set to the client, and schedules a new evaluation if there are
+// ports waiting for their stability window to expire.
+func (t *portTracker) evaluate(now time.Time) {
+	if t.ctx.Err() != nil {
+		return
+	}
+
+	var next time.Duration
+	schedule := func(wait time.Duration) {
+		if next == 0 || wait < next {
+			next = wait
+		}
+	}
+
+	t.departures = slices.DeleteFunc(t.departures, func(d *departure) bool {
+		return now.Sub(d.at) > CorrelationWindow
+	})
+
+	stable := []*discovery.Port{}
+	observations := t.observations[:0]
+	for _, obs := range t.observations {
+		if !obs.lostAt.IsZero() {
+			if !obs.announced {
+				// Disappeared before being announced
+				delete(t.index, portKey(obs.port))
+				continue
+			}
+			wait := RemoveGrace - now.Sub(obs.lostAt)
+			if wait <= 0 {
+				delete(t.index, portKey(obs.port))
+				continue
+			}
+			schedule(wait)
+		} else if !obs.announced {
+			if wait := stableAfter(obs.port) - now.Sub(obs.seenAt); wait > 0 {
+				schedule(wait)
+				observations = append(observations, obs)
+				continue
+			}
+			obs.announced = true
+			if d, props := correlate(obs.port, t.departures); d != nil {
+				t.departures = slices.DeleteFunc(t.departures, func(x *departure) bool { return x == d })
+				obs.inherited = props
+				obs.port.Properties.Merge(props)
+			}
+		}
+		stable = append(stable, obs.port)
+		observations = append(observations, obs)
+	}
+	t.observations = observations
+
+	stable = allowedPorts(stable)
+	processUpdates(t.stable, stable, t.eventCB)
+	t.stable = stable
+
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	if next > 0 {
+		t.timer = time.AfterFunc(next, func() {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+			t.evaluate(time.Now())
+		})
+	}
+}
+
+// stableAfter returns the time the port must stay connected before being
+// announced
+func stableAfter(port *discovery.Port) time.Duration {
+	if port.Protocol == "usb" {
+		return max(StableAfter, usbDeviceSettleTime)
+	}
+	return StableAfter
+}
+
+// collectPorts returns all the ports to be reported: the serial ports found
+// by the enumerator followed by the ones found by the additional sources
+func collectPorts(details []*enumerator.PortDetails) []*discovery.Port {
+	return append(toDiscoveryPorts(details), extraPorts()...)
+}
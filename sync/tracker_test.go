@@ -0,0 +1,181 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+)
+
+// trackerStep is an enumeration returning the given ports, or only an
+// evaluation of the pending ports if tick is set, at the given time
+type trackerStep struct {
+	at    time.Duration
+	ports []string
+	tick  bool
+}
+
+func TestPortTracker(t *testing.T) {
+	// The ports are identified by address and PID
+	ports := map[string]func() *discovery.Port{
+		"ttyS0":        func() *discovery.Port { return serialPort("/dev/ttyS0") },
+		"ttyACM0 8036": func() *discovery.Port { return usbPort("/dev/ttyACM0", "0x2341", "0x8036", "") },
+		"ttyACM0 0036": func() *discovery.Port { return usbPort("/dev/ttyACM0", "0x2341", "0x0036", "") },
+	}
+	tests := []struct {
+		name        string
+		stableAfter time.Duration
+		removeGrace time.Duration
+		initial     []string
+		steps       []trackerStep
+		events      []string
+	}{
+		{
+			name:    "no filters",
+			initial: []string{"ttyS0"},
+			steps: []trackerStep{
+				{at: 0, ports: []string{"ttyS0", "ttyACM0 8036"}},
+				{at: 10 * time.Millisecond, ports: []string{"ttyS0"}},
+			},
+			events: []string{"add ttyS0", "add ttyACM0 8036", "remove ttyACM0 8036"},
+		},
+		{
+			name:        "stable after the window",
+			stableAfter: 100 * time.Millisecond,
+			steps: []trackerStep{
+				{at: 0, ports: []string{"ttyS0"}},
+				{at: 99 * time.Millisecond, tick: true},
+				{at: 100 * time.Millisecond, tick: true},
+			},
+			events: []string{"add ttyS0"},
+		},
+		{
+			name:        "gone within the stable-after window",
+			stableAfter: 100 * time.Millisecond,
+			steps: []trackerStep{
+				{at: 0, ports: []string{"ttyS0"}},
+				{at: 50 * time.Millisecond, ports: []string{}},
+				{at: 200 * time.Millisecond, tick: true},
+			},
+			events: []string{},
+		},
+		{
+			name:        "back within the remove-grace window",
+			removeGrace: 100 * time.Millisecond,
+			initial:     []string{"ttyS0"},
+			steps: []trackerStep{
+				{at: 0, ports: []string{}},
+				{at: 50 * time.Millisecond, ports: []string{"ttyS0"}},
+				{at: 200 * time.Millisecond, tick: true},
+			},
+			events: []string{"add ttyS0"},
+		},
+		{
+			name:        "removed after the remove-grace window",
+			removeGrace: 100 * time.Millisecond,
+			initial:     []string{"ttyS0"},
+			steps: []trackerStep{
+				{at: 0, ports: []string{}},
+				{at: 99 * time.Millisecond, tick: true},
+				{at: 100 * time.Millisecond, tick: true},
+			},
+			events: []string{"add ttyS0", "remove ttyS0"},
+		},
+		{
+			name:        "address taken by another port within the remove-grace window",
+			removeGrace: 100 * time.Millisecond,
+			initial:     []string{"ttyACM0 8036"},
+			steps: []trackerStep{
+				{at: 0, ports: []string{}},
+				{at: 10 * time.Millisecond, ports: []string{"ttyACM0 0036"}},
+				{at: 200 * time.Millisecond, tick: true},
+			},
+			events: []string{"add ttyACM0 8036", "remove ttyACM0 8036", "add ttyACM0 0036"},
+		},
+		{
+			name:        "address taken by another port in the same enumeration",
+			removeGrace: 100 * time.Millisecond,
+			initial:     []string{"ttyACM0 8036"},
+			steps: []trackerStep{
+				{at: 0, ports: []string{"ttyACM0 0036"}},
+				{at: 200 * time.Millisecond, tick: true},
+			},
+			events: []string{"add ttyACM0 8036", "remove ttyACM0 8036", "add ttyACM0 0036"},
+		},
+		{
+			name:        "address taken by another port within both windows",
+			stableAfter: 50 * time.Millisecond,
+			removeGrace: 100 * time.Millisecond,
+			initial:     []string{"ttyACM0 8036"},
+			steps: []trackerStep{
+				{at: 0, ports: []string{}},
+				{at: 10 * time.Millisecond, ports: []string{"ttyACM0 0036"}},
+				{at: 60 * time.Millisecond, tick: true},
+				{at: 200 * time.Millisecond, tick: true},
+			},
+			events: []string{"add ttyACM0 8036", "remove ttyACM0 8036", "add ttyACM0 0036"},
+		},
+	}
+	defer func(stableAfter, removeGrace time.Duration) {
+		StableAfter, RemoveGrace = stableAfter, removeGrace
+	}(StableAfter, RemoveGrace)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			StableAfter, RemoveGrace = test.stableAfter, test.removeGrace
+			events := []string{}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			tracker := newPortTracker(ctx, func(event string, port *discovery.Port) {
+				name := port.Address[len("/dev/"):]
+				if pid := port.Properties.Get("pid"); pid != "" {
+					name += " " + pid[len("0x"):]
+				}
+				events = append(events, event+" "+name)
+			})
+			portsOf := func(names []string) []*discovery.Port {
+				res := []*discovery.Port{}
+				for _, name := range names {
+					res = append(res, ports[name]())
+				}
+				return res
+			}
+
+			// The timers of the tracker are never fired, the evaluations
+			// are driven by the steps with the simulated time
+			start := time.Now()
+			tracker.initPorts(portsOf(test.initial), start)
+			for _, step := range test.steps {
+				if step.tick {
+					tracker.lock.Lock()
+					tracker.evaluate(start.Add(step.at))
+					tracker.lock.Unlock()
+				} else {
+					tracker.updatePorts(portsOf(step.ports), start.Add(step.at))
+				}
+			}
+			cancel()
+			if !slices.Equal(events, test.events) {
+				t.Errorf("got events %q, want %q", events, test.events)
+			}
+		})
+	}
+}
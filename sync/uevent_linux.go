@@ -0,0 +1,65 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// ueventReader is a netlink socket receiving the kernel uevents. Unlike the
+// one of the uevent package, the socket address is assigned by the kernel,
+// so that more sync processes may run at the same time in the same program,
+// and closing the reader unblocks a pending Read.
+type ueventReader struct {
+	f *os.File
+}
+
+func newUeventReader() (io.ReadCloser, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC|unix.SOCK_NONBLOCK, unix.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return nil, err
+	}
+	// Pid 0 lets the kernel choose a unique address, group 1 receives the
+	// events sent by the kernel
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK, Groups: 1}); err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+	// The socket is non-blocking, so the file is handled by the runtime
+	// poller and Close wakes up a blocked Read
+	return &ueventReader{f: os.NewFile(uintptr(fd), "uevent")}, nil
+}
+
+// Read reads the next uevent message, it returns io.EOF once the reader has
+// been closed
+func (r *ueventReader) Read(p []byte) (int, error) {
+	n, err := r.f.Read(p)
+	if errors.Is(err, os.ErrClosed) {
+		return 0, io.EOF
+	}
+	return n, err
+}
+
+// Close closes the netlink socket
+func (r *ueventReader) Close() error {
+	return r.f.Close()
+}
@@ -0,0 +1,68 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	"github.com/arduino/go-properties-orderedmap"
+)
+
+// UF2Drives enables the discovery of the boards running a UF2 bootloader
+// (for example the RP2040 and the SAMD21/SAMD51 boards after a double tap
+// of the reset button): these boards are not exposed as a serial port but
+// as a USB mass storage drive, recognized by the INFO_UF2.TXT file in the
+// root of the mounted volume. The drives are reported with the "uf2"
+// protocol and the mount point as address. Supported only on Linux.
+var UF2Drives bool
+
+// uf2InfoFile is the name of the file that identifies a UF2 drive
+const uf2InfoFile = "INFO_UF2.TXT"
+
+// parseUF2Info adds to props the information contained in an INFO_UF2.TXT
+// file: the first line is the bootloader version, followed by "Key: value"
+// lines among which "Model" and "Board-ID" are the most common.
+func parseUF2Info(props *properties.Map, data []byte) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	first := true
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if first {
+			first = false
+			if !strings.Contains(line, ":") {
+				props.Set("uf2Bootloader", line)
+				continue
+			}
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "Model":
+			props.Set("uf2Model", strings.TrimSpace(value))
+		case "Board-ID":
+			props.Set("uf2BoardId", strings.TrimSpace(value))
+		}
+	}
+}
@@ -0,0 +1,165 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+	"golang.org/x/sys/unix"
+)
+
+// mountInfoPath is the file listing the mounted filesystems
+var mountInfoPath = "/proc/self/mountinfo"
+
+// uf2Ports scans the USB block devices in sysfs looking for the mounted
+// volumes that contain an INFO_UF2.TXT file
+func uf2Ports() []*discovery.Port {
+	mounts := readMountPoints()
+	if len(mounts) == 0 {
+		return nil
+	}
+	disks, _ := filepath.Glob(filepath.Join(sysfsRoot, "block", "*"))
+	res := []*discovery.Port{}
+	for _, disk := range disks {
+		dir, err := filepath.EvalSymlinks(disk)
+		if err != nil {
+			continue
+		}
+		usbDir := findUSBDeviceDir(dir)
+		if usbDir == "" {
+			continue
+		}
+		// The volume may be on the whole disk or on one of its partitions
+		name := filepath.Base(dir)
+		partitions, _ := filepath.Glob(filepath.Join(dir, name+"*"))
+		for _, volume := range append([]string{dir}, partitions...) {
+			mountPoint, ok := mounts[readSysfsAttr(volume, "dev")]
+			if !ok {
+				continue
+			}
+			info, err := os.ReadFile(filepath.Join(mountPoint, uf2InfoFile))
+			if err != nil {
+				continue
+			}
+			res = append(res, uf2Port(usbDir, "/dev/"+filepath.Base(volume), mountPoint, info))
+			break
+		}
+	}
+	return res
+}
+
+func uf2Port(usbDir, blockDevice, mountPoint string, info []byte) *discovery.Port {
+	props := usbDeviceProperties(usbDir)
+	setUSBDeviceIDs(props)
+	props.Set("mountPoint", mountPoint)
+	props.Set("blockDevice", blockDevice)
+	parseUF2Info(props, info)
+	return &discovery.Port{
+		Address:       mountPoint,
+		AddressLabel:  mountPoint,
+		Protocol:      "uf2",
+		ProtocolLabel: "UF2 Bootloader Drive",
+		Properties:    props,
+		HardwareID:    props.Get("serialNumber"),
+	}
+}
+
+// findUSBDeviceDir walks up the sysfs device tree from the given directory
+// and returns the directory of the USB device it belongs to, or an empty
+// string if it is not a USB device.
+func findUSBDeviceDir(dir string) string {
+	devicesRoot := filepath.Join(sysfsRoot, "devices")
+	for ; len(dir) > len(devicesRoot); dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, "idVendor")); err == nil {
+			return dir
+		}
+	}
+	return ""
+}
+
+// readMountPoints returns the mount point of the mounted block devices,
+// indexed by their "major:minor" device number
+func readMountPoints() map[string]string {
+	f, err := os.Open(mountInfoPath)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	res := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// 36 35 8:17 / /media/user/RPI-RP2 rw,nosuid,nodev shared:1 - vfat /dev/sdb1 rw
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		if _, ok := res[fields[2]]; !ok {
+			res[fields[2]] = unescapeMountPath(fields[4])
+		}
+	}
+	return res
+}
+
+// unescapeMountPath decodes the octal escapes (\040 for space, \011 for
+// tab, \012 for newline and \134 for backslash) of the paths in mountinfo
+func unescapeMountPath(path string) string {
+	if !strings.Contains(path, `\`) {
+		return path
+	}
+	var res strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+3 < len(path) && isOctal(path[i+1]) && isOctal(path[i+2]) && isOctal(path[i+3]) {
+			res.WriteByte((path[i+1]-'0')<<6 | (path[i+2]-'0')<<3 | (path[i+3] - '0'))
+			i += 3
+			continue
+		}
+		res.WriteByte(path[i])
+	}
+	return res.String()
+}
+
+func isOctal(c byte) bool {
+	return c >= '0' && c <= '7'
+}
+
+// watchMounts calls changedCB every time a filesystem is mounted or
+// unmounted, until the context is canceled. The kernel signals the changes
+// of the mount table with a POLLPRI event on the mountinfo file.
+func watchMounts(ctx context.Context, changedCB func()) {
+	f, err := os.Open(mountInfoPath)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fds := []unix.PollFd{{Fd: int32(f.Fd()), Events: unix.POLLPRI}}
+	for ctx.Err() == nil {
+		// Wake up periodically to check if the context has been canceled
+		n, err := unix.Poll(fds, 500)
+		if err != nil && err != unix.EINTR {
+			return
+		}
+		if n > 0 && fds[0].Revents&(unix.POLLPRI|unix.POLLERR) != 0 {
+			changedCB()
+		}
+	}
+}
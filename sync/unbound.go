@@ -0,0 +1,50 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	"strings"
+	"time"
+)
+
+// ReportUnbound enables the reporting of the USB devices with a known VID
+// that have no serial port, for example because the cdc_acm driver is
+// missing or because the board is stuck in a bootloader that does not
+// expose a CDC interface. These devices are reported with the "usb"
+// protocol, so clients do not try to open them, along with the reason why
+// no serial port is available. Supported only on Linux.
+var ReportUnbound bool
+
+// UnboundVIDs are the USB VIDs of the devices checked when ReportUnbound is
+// enabled, in addition to the VIDs of the boards in the Boards index.
+var UnboundVIDs = []string{"2341", "2a03"}
+
+// usbDeviceSettleTime is the minimum time a USB device must be seen without
+// a serial port before being reported: the OS may need some time to bind
+// the drivers to a just connected device.
+const usbDeviceSettleTime = 2 * time.Second
+
+func isUnboundVID(vid string) bool {
+	vid = strings.ToLower(vid)
+	for _, v := range UnboundVIDs {
+		if strings.ToLower(v) == vid {
+			return true
+		}
+	}
+	return Boards != nil && Boards.HasVendor(vid)
+}
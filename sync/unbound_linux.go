@@ -0,0 +1,90 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	"os"
+	"path/filepath"
+
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+)
+
+// unboundPorts scans the USB devices in sysfs looking for the ones with a
+// known VID that have no serial port
+func unboundPorts() []*discovery.Port {
+	res := []*discovery.Port{}
+	for _, dir := range usbDeviceDirs() {
+		name := filepath.Base(dir)
+		vid := readSysfsAttr(dir, "idVendor")
+		if !isUnboundVID(vid) {
+			continue
+		}
+		interfaces, _ := filepath.Glob(filepath.Join(dir, name+":*"))
+		reason := unboundReason(interfaces)
+		if reason == "" || (reason == "dfu_only" && DFUDevices) {
+			// DFU devices are reported by their own source when enabled
+			continue
+		}
+
+		props := usbDeviceProperties(dir)
+		props.Set("reason", reason)
+		res = append(res, &discovery.Port{
+			Address:       name,
+			AddressLabel:  name,
+			Protocol:      "usb",
+			ProtocolLabel: "USB device (no serial port)",
+			Properties:    props,
+			HardwareID:    props.Get("serialNumber"),
+		})
+	}
+	return res
+}
+
+// unboundReason returns why the USB device with the given interfaces has no
+// serial port, or an empty string if it has one (or it is going to have one)
+func unboundReason(interfaces []string) string {
+	hasACM, hasDFU := false, false
+	for _, intf := range interfaces {
+		if ttys, _ := filepath.Glob(filepath.Join(intf, "tty", "*")); len(ttys) > 0 {
+			return ""
+		}
+		if ttys, _ := filepath.Glob(filepath.Join(intf, "ttyUSB*")); len(ttys) > 0 {
+			return ""
+		}
+		class := readSysfsAttr(intf, "bInterfaceClass")
+		subClass := readSysfsAttr(intf, "bInterfaceSubClass")
+		switch {
+		case class == "02" && subClass == "02":
+			if _, err := os.Lstat(filepath.Join(intf, "driver")); err != nil {
+				return "driver_missing"
+			}
+			// The driver is bound, the tty is going to be created
+			hasACM = true
+		case class == "fe" && subClass == "01":
+			hasDFU = true
+		}
+	}
+	switch {
+	case hasACM:
+		return ""
+	case hasDFU:
+		return "dfu_only"
+	default:
+		return "no_cdc_interface"
+	}
+}
@@ -0,0 +1,68 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/arduino/go-properties-orderedmap"
+	"github.com/arduino/serial-discovery/usbdesc"
+	"go.bug.st/serial/enumerator"
+)
+
+// usbDevice contains the information about the USB device of a serial port
+// gathered from the OS, in addition to the details given by the enumerator.
+type usbDevice struct {
+	// path is the position of the device in the USB topology, for example
+	// "1-1.2" for a device connected to port 2 of a hub plugged in port 1
+	// of bus 1
+	path string
+	// bcdDevice is the device release number, as a 4 digits hex string
+	bcdDevice string
+	// interfaceNumber is the number of the USB interface of the port, or -1
+	// if not known
+	interfaceNumber int
+	// driver is the name of the driver bound to the USB interface
+	driver string
+	// interfaceName is the string descriptor of the USB interface
+	interfaceName string
+	// descriptors are the USB descriptors of the device, if available
+	descriptors *usbdesc.Device
+	// configuration is the value of the active configuration
+	configuration uint8
+}
+
+// setDeviceProperties adds the "deviceId" property, shared by all the ports
+// of the same USB device, and the "interfaceIndex" of the port within the
+// device. Without the USB topology the ports are grouped by VID, PID and
+// serial number, if the serial number is not available each port is
+// considered a different device.
+func setDeviceProperties(props *properties.Map, port *enumerator.PortDetails, dev *usbDevice) {
+	switch {
+	case dev != nil:
+		props.Set("deviceId", "usb:"+dev.path)
+	case port.SerialNumber != "":
+		props.Set("deviceId", "usb:"+strings.ToLower(port.VID+":"+port.PID)+":"+port.SerialNumber)
+	default:
+		props.Set("deviceId", port.Name)
+	}
+	if dev != nil && dev.interfaceNumber >= 0 {
+		props.Set("interfaceIndex", strconv.Itoa(dev.interfaceNumber))
+	}
+}
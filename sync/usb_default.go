@@ -0,0 +1,42 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+//go:build !linux
+
+package sync
+
+import (
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+)
+
+// extraPorts returns the ports found by the additional sources enabled,
+// they are not supported on this OS.
+func extraPorts() []*discovery.Port {
+	return nil
+}
+
+// lookupUSBDevice returns the USB device the given port belongs to, the USB
+// topology is not available on this OS.
+func lookupUSBDevice(_ string) *usbDevice {
+	return nil
+}
+
+// lookupByID returns the persistent name of the serial port, not available
+// on this OS.
+func lookupByID(_ string) string {
+	return ""
+}
@@ -0,0 +1,185 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/arduino/go-properties-orderedmap"
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+	"github.com/arduino/serial-discovery/usbdesc"
+)
+
+// sysfsRoot is the mount point of sysfs
+var sysfsRoot = "/sys"
+
+// extraPorts returns the ports found by the additional sources enabled
+func extraPorts() []*discovery.Port {
+	var res []*discovery.Port
+	if ReportUnbound {
+		res = append(res, unboundPorts()...)
+	}
+	if UF2Drives {
+		res = append(res, uf2Ports()...)
+	}
+	if DFUDevices {
+		res = append(res, dfuPorts()...)
+	}
+	return res
+}
+
+// watchSubsystem returns true if the port list must be updated on the
+// events of the given kernel subsystem
+func watchSubsystem(subsystem string) bool {
+	switch subsystem {
+	case "tty":
+		return true
+	case "usb":
+		return ReportUnbound || DFUDevices
+	case "block":
+		return UF2Drives
+	default:
+		return false
+	}
+}
+
+// usbDeviceDirs returns the sysfs directories of the connected USB devices,
+// excluding the root hubs
+func usbDeviceDirs() []string {
+	entries, _ := filepath.Glob(filepath.Join(sysfsRoot, "bus", "usb", "devices", "*"))
+	res := []string{}
+	for _, dir := range entries {
+		name := filepath.Base(dir)
+		if strings.Contains(name, ":") || strings.HasPrefix(name, "usb") {
+			// Skip interfaces and root hubs
+			continue
+		}
+		res = append(res, dir)
+	}
+	return res
+}
+
+// usbDeviceProperties returns the properties of the USB device with the
+// given sysfs directory, used by the sources that report USB devices
+// without a serial port
+func usbDeviceProperties(dir string) *properties.Map {
+	vid := readSysfsAttr(dir, "idVendor")
+	pid := readSysfsAttr(dir, "idProduct")
+	props := properties.NewMap()
+	props.Set("vid", "0x"+vid)
+	props.Set("pid", "0x"+pid)
+	props.Set("serialNumber", readSysfsAttr(dir, "serial"))
+	if manufacturer := readSysfsAttr(dir, "manufacturer"); manufacturer != "" {
+		props.Set("manufacturer", manufacturer)
+	}
+	if product := readSysfsAttr(dir, "product"); product != "" {
+		props.Set("product", product)
+	}
+	setUSBIDsProperties(props, vid, pid)
+	setBoardProperties(props, vid, pid)
+	props.Set("usbPath", filepath.Base(dir))
+	return props
+}
+
+// setUSBDeviceIDs adds the "deviceId" and "physicalId" properties of a USB
+// device without a serial port, the properties returned by
+// usbDeviceProperties must be already set
+func setUSBDeviceIDs(props *properties.Map) {
+	usbPath := props.Get("usbPath")
+	props.Set("deviceId", "usb:"+usbPath)
+	if serialNumber := props.Get("serialNumber"); serialNumber != "" {
+		props.Set("physicalId", serialNumber)
+	} else {
+		props.Set("physicalId", "usb:"+usbPath)
+	}
+}
+
+// lookupUSBDevice returns the USB device the given tty belongs to, by
+// walking up the sysfs device tree from the tty node until the directory
+// of the USB device is found.
+func lookupUSBDevice(portName string) *usbDevice {
+	dir, err := filepath.EvalSymlinks(filepath.Join(sysfsRoot, "class", "tty", filepath.Base(portName), "device"))
+	if err != nil {
+		return nil
+	}
+	dev := &usbDevice{interfaceNumber: -1}
+	devicesRoot := filepath.Join(sysfsRoot, "devices")
+	for ; len(dir) > len(devicesRoot); dir = filepath.Dir(dir) {
+		if dev.interfaceNumber == -1 {
+			if n, err := strconv.ParseUint(readSysfsAttr(dir, "bInterfaceNumber"), 16, 8); err == nil {
+				dev.interfaceNumber = int(n)
+				if driver, err := os.Readlink(filepath.Join(dir, "driver")); err == nil {
+					dev.driver = filepath.Base(driver)
+				}
+				dev.interfaceName = readSysfsAttr(dir, "interface")
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dir, "idVendor")); err == nil {
+			dev.path = filepath.Base(dir)
+			dev.bcdDevice = readSysfsAttr(dir, "bcdDevice")
+			if data, err := os.ReadFile(filepath.Join(dir, "descriptors")); err == nil {
+				dev.descriptors, _ = usbdesc.Parse(data)
+			}
+			if n, err := strconv.ParseUint(readSysfsAttr(dir, "bConfigurationValue"), 10, 8); err == nil {
+				dev.configuration = uint8(n)
+			}
+			return dev
+		}
+	}
+	return nil
+}
+
+// serialByIDDir is the directory of the persistent serial port names created
+// by udev
+var serialByIDDir = "/dev/serial/by-id"
+
+// udevControl exists while udev is running
+var udevControl = "/run/udev/control"
+
+// lookupByID returns the persistent name of the serial port, that is the
+// path of the link to the port in /dev/serial/by-id, or an empty string if
+// there is no link
+func lookupByID(portName string) string {
+	links, _ := filepath.Glob(filepath.Join(serialByIDDir, "*"))
+	for _, link := range links {
+		if target, err := filepath.EvalSymlinks(link); err == nil && target == portName {
+			return link
+		}
+	}
+	return ""
+}
+
+// udevRunning returns true if udev is managing the device nodes, and so it
+// is going to create the persistent names of the serial ports
+func udevRunning() bool {
+	_, err := os.Stat(udevControl)
+	return err == nil
+}
+
+// readSysfsAttr returns the value of a sysfs attribute, or an empty string
+// if the attribute cannot be read
+func readSysfsAttr(dir, attr string) string {
+	data, err := os.ReadFile(filepath.Join(dir, attr))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
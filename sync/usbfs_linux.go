@@ -0,0 +1,140 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"unicode/utf16"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// usbfsRoot is the directory of the usbfs device nodes
+var usbfsRoot = "/dev/bus/usb"
+
+// usbCtrlTransfer is the usbdevfs_ctrltransfer argument of the
+// USBDEVFS_CONTROL ioctl
+type usbCtrlTransfer struct {
+	requestType uint8
+	request     uint8
+	value       uint16
+	index       uint16
+	length      uint16
+	timeout     uint32
+	data        unsafe.Pointer
+}
+
+// usbdevfsControl is the USBDEVFS_CONTROL ioctl request, that is
+// _IOWR('U', 0, struct usbdevfs_ctrltransfer)
+var usbdevfsControl = func() uintptr {
+	read, write, sizeBits := uintptr(2), uintptr(1), 14
+	switch runtime.GOARCH {
+	case "mips", "mipsle", "mips64", "mips64le", "ppc64", "ppc64le":
+		read, write, sizeBits = 2, 4, 13
+	}
+	size := unsafe.Sizeof(usbCtrlTransfer{})
+	return (read|write)<<(16+sizeBits) | size<<16 | 'U'<<8
+}()
+
+// usbStringReader reads the string descriptors of a USB device through usbfs
+type usbStringReader struct {
+	f      *os.File
+	langID uint16
+}
+
+// openUSBStrings opens the usbfs node of the USB device with the given sysfs
+// directory. The node is usually writable only by root, unless a udev rule
+// grants access to the device.
+func openUSBStrings(dir string) (*usbStringReader, error) {
+	bus, err := strconv.Atoi(readSysfsAttr(dir, "busnum"))
+	if err != nil {
+		return nil, err
+	}
+	dev, err := strconv.Atoi(readSysfsAttr(dir, "devnum"))
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(usbfsRoot, fmt.Sprintf("%03d", bus), fmt.Sprintf("%03d", dev)), os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	r := &usbStringReader{f: f}
+	// The string descriptor 0 is the list of the supported languages
+	langs, err := r.getDescriptor(0)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("reading languages: %w", err)
+	}
+	if len(langs) < 2 {
+		f.Close()
+		return nil, errors.New("no supported languages")
+	}
+	r.langID = uint16(langs[0]) | uint16(langs[1])<<8
+	return r, nil
+}
+
+// Get returns the string descriptor with the given index
+func (r *usbStringReader) Get(index uint8) (string, error) {
+	if index == 0 {
+		return "", nil
+	}
+	data, err := r.getDescriptor(index)
+	if err != nil {
+		return "", err
+	}
+	chars := make([]uint16, len(data)/2)
+	for i := range chars {
+		chars[i] = uint16(data[2*i]) | uint16(data[2*i+1])<<8
+	}
+	return string(utf16.Decode(chars)), nil
+}
+
+// Close closes the usbfs node
+func (r *usbStringReader) Close() error {
+	return r.f.Close()
+}
+
+// getDescriptor sends a GET_DESCRIPTOR request for a string descriptor and
+// returns its content without the header
+func (r *usbStringReader) getDescriptor(index uint8) ([]byte, error) {
+	buf := make([]byte, 255)
+	ctrl := usbCtrlTransfer{
+		requestType: 0x80, // Device to host, standard, device
+		request:     0x06, // GET_DESCRIPTOR
+		value:       0x03<<8 | uint16(index),
+		index:       r.langID,
+		length:      uint16(len(buf)),
+		timeout:     1000,
+		data:        unsafe.Pointer(&buf[0]),
+	}
+	n, _, errno := unix.Syscall(unix.SYS_IOCTL, r.f.Fd(), usbdevfsControl, uintptr(unsafe.Pointer(&ctrl)))
+	runtime.KeepAlive(buf)
+	if errno != 0 {
+		return nil, errno
+	}
+	if n < 2 || buf[0] < 2 || int(buf[0]) > int(n) || buf[1] != 0x03 {
+		return nil, fmt.Errorf("invalid string descriptor %d", index)
+	}
+	return buf[2:buf[0]], nil
+}
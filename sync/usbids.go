@@ -0,0 +1,42 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package sync
+
+import (
+	"github.com/arduino/go-properties-orderedmap"
+	"github.com/arduino/serial-discovery/usbids"
+)
+
+// USBIDs is used, if not nil, to add the vendor and product names to the
+// USB ports
+var USBIDs *usbids.Database
+
+// setUSBIDsProperties adds the "vendorName" and "productName" properties
+// taken from the usb.ids database
+func setUSBIDsProperties(props *properties.Map, vid, pid string) {
+	if USBIDs == nil {
+		return
+	}
+	vendorName, productName := USBIDs.Lookup(vid, pid)
+	if vendorName != "" {
+		props.Set("vendorName", vendorName)
+	}
+	if productName != "" {
+		props.Set("productName", productName)
+	}
+}
@@ -0,0 +1,351 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+// Package usbdesc parses the binary USB descriptors of a device, as exposed
+// for example by the "descriptors" file of the Linux sysfs.
+package usbdesc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Descriptor types
+const (
+	TypeDevice               = 0x01
+	TypeConfiguration        = 0x02
+	TypeInterface            = 0x04
+	TypeEndpoint             = 0x05
+	TypeInterfaceAssociation = 0x0B
+	TypeDFUFunctional        = 0x21
+	TypeCSInterface          = 0x24
+)
+
+// Interface classes
+const (
+	ClassCDC                 = 0x02
+	ClassCDCData             = 0x0A
+	ClassApplicationSpecific = 0xFE
+)
+
+// Subclass and protocols of the DFU interfaces, the runtime protocol is used
+// by the devices running their application, the DFU mode protocol by the
+// devices running the DFU bootloader
+const (
+	SubClassDFU        = 0x01
+	ProtocolDFURuntime = 0x01
+	ProtocolDFUMode    = 0x02
+)
+
+// Device is a USB device descriptor, along with the descriptors of its
+// configurations
+type Device struct {
+	BcdUSB            uint16
+	Class             uint8
+	SubClass          uint8
+	Protocol          uint8
+	MaxPacketSize0    uint8
+	VendorID          uint16
+	ProductID         uint16
+	BcdDevice         uint16
+	IManufacturer     uint8
+	IProduct          uint8
+	ISerialNumber     uint8
+	NumConfigurations uint8
+	Configurations    []*Configuration
+}
+
+// Configuration is a USB configuration descriptor, along with the
+// descriptors of its interfaces
+type Configuration struct {
+	NumInterfaces  uint8
+	Value          uint8
+	IConfiguration uint8
+	Attributes     uint8
+	// MaxPower is the maximum power consumption in mA
+	MaxPower     int
+	Interfaces   []*Interface
+	Associations []*InterfaceAssociation
+}
+
+// InterfaceAssociation is a USB Interface Association Descriptor (IAD),
+// used to group the interfaces of the same function
+type InterfaceAssociation struct {
+	FirstInterface uint8
+	InterfaceCount uint8
+	Class          uint8
+	SubClass       uint8
+	Protocol       uint8
+	IFunction      uint8
+}
+
+// Interface is a USB interface descriptor, along with the descriptors of
+// its endpoints and its class-specific descriptors
+type Interface struct {
+	Number           uint8
+	AlternateSetting uint8
+	NumEndpoints     uint8
+	Class            uint8
+	SubClass         uint8
+	Protocol         uint8
+	IInterface       uint8
+	Endpoints        []*Endpoint
+	// CDC contains the CDC functional descriptors, if any
+	CDC *CDCFunctional
+	// DFU contains the DFU functional descriptor, if any
+	DFU *DFUFunctional
+}
+
+// IsDFU returns true if the interface is a DFU interface
+func (i *Interface) IsDFU() bool {
+	return i.Class == ClassApplicationSpecific && i.SubClass == SubClassDFU
+}
+
+// Endpoint is a USB endpoint descriptor
+type Endpoint struct {
+	Address       uint8
+	Attributes    uint8
+	MaxPacketSize uint16
+	Interval      uint8
+}
+
+// CDCFunctional contains the CDC functional descriptors of an interface
+type CDCFunctional struct {
+	BcdCDC                     uint16
+	HasCallManagement          bool
+	CallManagementCapabilities uint8
+	DataInterface              uint8
+	HasACM                     bool
+	ACMCapabilities            uint8
+	HasUnion                   bool
+	ControlInterface           uint8
+	SubordinateInterfaces      []uint8
+}
+
+// DFUFunctional is the DFU functional descriptor of an interface
+type DFUFunctional struct {
+	Attributes    uint8
+	DetachTimeout uint16
+	TransferSize  uint16
+	BcdDFU        uint16
+}
+
+// AttributeNames returns the names of the attributes declared in the DFU
+// functional descriptor
+func (d *DFUFunctional) AttributeNames() []string {
+	names := []string{"can_download", "can_upload", "manifestation_tolerant", "will_detach"}
+	res := []string{}
+	for bit, name := range names {
+		if d.Attributes&(1<<bit) != 0 {
+			res = append(res, name)
+		}
+	}
+	return res
+}
+
+// ACMCapabilityNames returns the names of the capabilities declared in the
+// Abstract Control Management functional descriptor
+func (c *CDCFunctional) ACMCapabilityNames() []string {
+	names := []string{"comm_feature", "line_coding", "send_break", "network_connection"}
+	res := []string{}
+	for bit, name := range names {
+		if c.ACMCapabilities&(1<<bit) != 0 {
+			res = append(res, name)
+		}
+	}
+	return res
+}
+
+// Parse parses a device descriptor followed by the descriptors of its
+// configurations
+func Parse(data []byte) (*Device, error) {
+	var dev *Device
+	var config *Configuration
+	var intf *Interface
+	for len(data) > 0 {
+		if len(data) < 2 {
+			return nil, errors.New("truncated descriptor")
+		}
+		length, descType := int(data[0]), data[1]
+		if length < 2 || length > len(data) {
+			return nil, fmt.Errorf("invalid descriptor length %d", length)
+		}
+		desc := data[:length]
+		data = data[length:]
+
+		if dev == nil {
+			if descType != TypeDevice {
+				return nil, errors.New("missing device descriptor")
+			}
+			if length < 18 {
+				return nil, errors.New("device descriptor too short")
+			}
+			dev = &Device{
+				BcdUSB:            binary.LittleEndian.Uint16(desc[2:]),
+				Class:             desc[4],
+				SubClass:          desc[5],
+				Protocol:          desc[6],
+				MaxPacketSize0:    desc[7],
+				VendorID:          binary.LittleEndian.Uint16(desc[8:]),
+				ProductID:         binary.LittleEndian.Uint16(desc[10:]),
+				BcdDevice:         binary.LittleEndian.Uint16(desc[12:]),
+				IManufacturer:     desc[14],
+				IProduct:          desc[15],
+				ISerialNumber:     desc[16],
+				NumConfigurations: desc[17],
+			}
+			continue
+		}
+
+		switch descType {
+		case TypeConfiguration:
+			if length < 9 {
+				return nil, errors.New("configuration descriptor too short")
+			}
+			// bMaxPower is in 2 mA units, or 8 mA units for SuperSpeed devices
+			powerUnit := 2
+			if dev.BcdUSB >= 0x0300 {
+				powerUnit = 8
+			}
+			config = &Configuration{
+				NumInterfaces:  desc[4],
+				Value:          desc[5],
+				IConfiguration: desc[6],
+				Attributes:     desc[7],
+				MaxPower:       int(desc[8]) * powerUnit,
+			}
+			intf = nil
+			dev.Configurations = append(dev.Configurations, config)
+		case TypeInterfaceAssociation:
+			if config == nil || length < 8 {
+				continue
+			}
+			config.Associations = append(config.Associations, &InterfaceAssociation{
+				FirstInterface: desc[2],
+				InterfaceCount: desc[3],
+				Class:          desc[4],
+				SubClass:       desc[5],
+				Protocol:       desc[6],
+				IFunction:      desc[7],
+			})
+		case TypeInterface:
+			if config == nil || length < 9 {
+				continue
+			}
+			intf = &Interface{
+				Number:           desc[2],
+				AlternateSetting: desc[3],
+				NumEndpoints:     desc[4],
+				Class:            desc[5],
+				SubClass:         desc[6],
+				Protocol:         desc[7],
+				IInterface:       desc[8],
+			}
+			config.Interfaces = append(config.Interfaces, intf)
+		case TypeEndpoint:
+			if intf == nil || length < 7 {
+				continue
+			}
+			intf.Endpoints = append(intf.Endpoints, &Endpoint{
+				Address:       desc[2],
+				Attributes:    desc[3],
+				MaxPacketSize: binary.LittleEndian.Uint16(desc[4:]),
+				Interval:      desc[6],
+			})
+		case TypeCSInterface:
+			if intf == nil || intf.Class != ClassCDC || length < 3 {
+				continue
+			}
+			parseCDCFunctional(intf, desc)
+		case TypeDFUFunctional:
+			// The same descriptor type is used by other classes (HID)
+			if intf == nil || !intf.IsDFU() || length < 7 {
+				continue
+			}
+			intf.DFU = &DFUFunctional{
+				Attributes:    desc[2],
+				DetachTimeout: binary.LittleEndian.Uint16(desc[3:]),
+				TransferSize:  binary.LittleEndian.Uint16(desc[5:]),
+			}
+			if length >= 9 {
+				intf.DFU.BcdDFU = binary.LittleEndian.Uint16(desc[7:])
+			}
+		}
+	}
+	if dev == nil {
+		return nil, errors.New("missing device descriptor")
+	}
+	return dev, nil
+}
+
+// parseCDCFunctional parses a CDC functional descriptor of the interface
+func parseCDCFunctional(intf *Interface, desc []byte) {
+	if intf.CDC == nil {
+		intf.CDC = &CDCFunctional{}
+	}
+	cdc := intf.CDC
+	switch subType := desc[2]; {
+	case subType == 0x00 && len(desc) >= 5: // Header
+		cdc.BcdCDC = binary.LittleEndian.Uint16(desc[3:])
+	case subType == 0x01 && len(desc) >= 5: // Call Management
+		cdc.HasCallManagement = true
+		cdc.CallManagementCapabilities = desc[3]
+		cdc.DataInterface = desc[4]
+	case subType == 0x02 && len(desc) >= 4: // Abstract Control Management
+		cdc.HasACM = true
+		cdc.ACMCapabilities = desc[3]
+	case subType == 0x06 && len(desc) >= 4: // Union
+		cdc.HasUnion = true
+		cdc.ControlInterface = desc[3]
+		cdc.SubordinateInterfaces = append([]uint8{}, desc[4:]...)
+	}
+}
+
+// Interface returns the descriptor of the given interface and alternate
+// setting, or nil if not found
+func (c *Configuration) Interface(number, alternateSetting uint8) *Interface {
+	for _, intf := range c.Interfaces {
+		if intf.Number == number && intf.AlternateSetting == alternateSetting {
+			return intf
+		}
+	}
+	return nil
+}
+
+// AlternateSettings returns the descriptors of all the alternate settings of
+// the given interface
+func (c *Configuration) AlternateSettings(number uint8) []*Interface {
+	res := []*Interface{}
+	for _, intf := range c.Interfaces {
+		if intf.Number == number {
+			res = append(res, intf)
+		}
+	}
+	return res
+}
+
+// Configuration returns the configuration with the given value, or nil if
+// not found
+func (d *Device) Configuration(value uint8) *Configuration {
+	for _, config := range d.Configurations {
+		if config.Value == value {
+			return config
+		}
+	}
+	return nil
+}
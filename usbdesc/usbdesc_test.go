@@ -0,0 +1,251 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package usbdesc
+
+import (
+	"encoding/hex"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// The blobs below are the content of the sysfs "descriptors" file of the
+// devices, one descriptor per line.
+
+// Arduino MKR WiFi 1010 running a sketch: a CDC-ACM function grouped by an
+// Interface Association Descriptor
+const cdcACMWithIAD = `
+12 01 00 02 ef 02 01 40 41 23 54 80 00 01 01 02 03 01
+09 02 4b 00 02 01 00 80 fa
+08 0b 00 02 02 02 00 00
+09 04 00 00 01 02 02 00 00
+05 24 00 10 01
+05 24 01 01 01
+04 24 02 06
+05 24 06 00 01
+07 05 81 03 10 00 10
+09 04 01 00 02 0a 00 00 00
+07 05 02 02 40 00 00
+07 05 83 02 40 00 00
+`
+
+// FTDI FT2232H: two vendor specific interfaces, one per channel
+const ft2232H = `
+12 01 00 02 00 00 00 40 03 04 10 60 00 07 01 02 03 01
+09 02 37 00 02 01 00 80 2d
+09 04 00 00 02 ff ff ff 02
+07 05 81 02 00 02 00
+07 05 02 02 00 02 00
+09 04 01 00 02 ff ff ff 02
+07 05 83 02 00 02 00
+07 05 04 02 00 02 00
+`
+
+// STM32 ROM bootloader in DFU mode: one alternate setting per memory region
+// followed by the DFU functional descriptor
+const stm32DFU = `
+12 01 00 02 00 00 00 40 83 04 11 df 00 22 01 02 03 01
+09 02 36 00 01 01 04 c0 32
+09 04 00 00 00 fe 01 02 04
+09 04 00 01 00 fe 01 02 05
+09 04 00 02 00 fe 01 02 06
+09 04 00 03 00 fe 01 02 07
+09 21 0b ff 00 00 08 1a 01
+`
+
+func blob(t testing.TB, dump string) []byte {
+	data, err := hex.DecodeString(strings.Join(strings.Fields(dump), ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseCDCACMWithIAD(t *testing.T) {
+	dev, err := Parse(blob(t, cdcACMWithIAD))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dev.VendorID != 0x2341 || dev.ProductID != 0x8054 || dev.BcdDevice != 0x0100 || dev.Class != 0xef {
+		t.Errorf("unexpected device descriptor %+v", dev)
+	}
+	if len(dev.Configurations) != 1 {
+		t.Fatalf("got %d configurations, want 1", len(dev.Configurations))
+	}
+	config := dev.Configuration(1)
+	if config == nil || config.MaxPower != 500 || len(config.Interfaces) != 2 {
+		t.Fatalf("unexpected configuration %+v", config)
+	}
+	if len(config.Associations) != 1 {
+		t.Fatalf("got %d interface associations, want 1", len(config.Associations))
+	}
+	if iad := config.Associations[0]; iad.FirstInterface != 0 || iad.InterfaceCount != 2 || iad.Class != ClassCDC {
+		t.Errorf("unexpected interface association %+v", iad)
+	}
+
+	comm := config.Interface(0, 0)
+	if comm == nil || comm.Class != ClassCDC || comm.SubClass != 0x02 || comm.Protocol != 0x00 {
+		t.Fatalf("unexpected communication interface %+v", comm)
+	}
+	if len(comm.Endpoints) != 1 || comm.Endpoints[0].Address != 0x81 || comm.Endpoints[0].Attributes != 0x03 ||
+		comm.Endpoints[0].MaxPacketSize != 16 || comm.Endpoints[0].Interval != 16 {
+		t.Errorf("unexpected notification endpoint %+v", comm.Endpoints)
+	}
+	cdc := comm.CDC
+	if cdc == nil {
+		t.Fatal("missing CDC functional descriptors")
+	}
+	if cdc.BcdCDC != 0x0110 || !cdc.HasCallManagement || cdc.DataInterface != 1 || !cdc.HasACM ||
+		!cdc.HasUnion || cdc.ControlInterface != 0 || !slices.Equal(cdc.SubordinateInterfaces, []uint8{1}) {
+		t.Errorf("unexpected CDC functional descriptors %+v", cdc)
+	}
+	if names := cdc.ACMCapabilityNames(); !slices.Equal(names, []string{"line_coding", "send_break"}) {
+		t.Errorf("got ACM capabilities %q", names)
+	}
+
+	data := config.Interface(1, 0)
+	if data == nil || data.Class != ClassCDCData || data.CDC != nil || len(data.Endpoints) != 2 {
+		t.Fatalf("unexpected data interface %+v", data)
+	}
+	if data.Endpoints[0].Address != 0x02 || data.Endpoints[1].Address != 0x83 || data.Endpoints[1].MaxPacketSize != 64 {
+		t.Errorf("unexpected data endpoints %+v %+v", data.Endpoints[0], data.Endpoints[1])
+	}
+}
+
+func TestParseFT2232H(t *testing.T) {
+	dev, err := Parse(blob(t, ft2232H))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dev.VendorID != 0x0403 || dev.ProductID != 0x6010 || dev.BcdDevice != 0x0700 {
+		t.Errorf("unexpected device descriptor %+v", dev)
+	}
+	config := dev.Configuration(1)
+	if config == nil || config.MaxPower != 90 || len(config.Associations) != 0 {
+		t.Fatalf("unexpected configuration %+v", config)
+	}
+	if len(config.Interfaces) != 2 {
+		t.Fatalf("got %d interfaces, want 2", len(config.Interfaces))
+	}
+	for i, intf := range config.Interfaces {
+		if intf.Number != uint8(i) || intf.Class != 0xff || intf.IInterface != 2 || intf.CDC != nil || intf.IsDFU() {
+			t.Errorf("unexpected interface %+v", intf)
+		}
+		if len(intf.Endpoints) != 2 || intf.Endpoints[0].MaxPacketSize != 512 {
+			t.Errorf("unexpected endpoints of interface %d", i)
+		}
+	}
+	if config.Interfaces[1].Endpoints[0].Address != 0x83 || config.Interfaces[1].Endpoints[1].Address != 0x04 {
+		t.Errorf("unexpected endpoints of the second channel")
+	}
+}
+
+func TestParseDFUAlternateSettings(t *testing.T) {
+	dev, err := Parse(blob(t, stm32DFU))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dev.VendorID != 0x0483 || dev.ProductID != 0xdf11 || dev.BcdDevice != 0x2200 {
+		t.Errorf("unexpected device descriptor %+v", dev)
+	}
+	config := dev.Configuration(1)
+	if config == nil || config.MaxPower != 100 || config.IConfiguration != 4 {
+		t.Fatalf("unexpected configuration %+v", config)
+	}
+	alts := config.AlternateSettings(0)
+	if len(alts) != 4 {
+		t.Fatalf("got %d alternate settings, want 4", len(alts))
+	}
+	for i, alt := range alts {
+		if alt.AlternateSetting != uint8(i) || alt.IInterface != uint8(4+i) || !alt.IsDFU() ||
+			alt.Protocol != ProtocolDFUMode || len(alt.Endpoints) != 0 {
+			t.Errorf("unexpected alternate setting %+v", alt)
+		}
+	}
+	// The functional descriptor follows the last alternate setting
+	dfu := alts[3].DFU
+	if dfu == nil {
+		t.Fatal("missing DFU functional descriptor")
+	}
+	if dfu.DetachTimeout != 255 || dfu.TransferSize != 2048 || dfu.BcdDFU != 0x011a {
+		t.Errorf("unexpected DFU functional descriptor %+v", dfu)
+	}
+	if names := dfu.AttributeNames(); !slices.Equal(names, []string{"can_download", "can_upload", "will_detach"}) {
+		t.Errorf("got DFU attributes %q", names)
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		dump string
+	}{
+		{"empty", ``},
+		{"missing device descriptor", `09 02 37 00 02 01 00 80 2d`},
+		{"short device descriptor", `08 01 00 02 00 00 00 40`},
+		{"zero bLength", `12 01 00 02 00 00 00 40 03 04 10 60 00 07 01 02 03 01 00 02`},
+		{"one byte bLength", `12 01 00 02 00 00 00 40 03 04 10 60 00 07 01 02 03 01 01 02`},
+		{"bLength past the end", `12 01 00 02 00 00 00 40 03 04 10 60 00 07 01 02 03 01 09 02 37 00`},
+		{"truncated device descriptor", `12 01 00 02 00 00 00 40 03 04`},
+		{"dangling byte", `12 01 00 02 00 00 00 40 03 04 10 60 00 07 01 02 03 01 09`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if dev, err := Parse(blob(t, test.dump)); err == nil {
+				t.Errorf("got %+v, want an error", dev)
+			}
+		})
+	}
+}
+
+func TestParseTruncated(t *testing.T) {
+	// Every truncation of a valid blob is either rejected or parsed as the
+	// descriptors that precede the cut, but it never panics
+	for _, dump := range []string{cdcACMWithIAD, ft2232H, stm32DFU} {
+		data := blob(t, dump)
+		for n := range len(data) {
+			dev, err := Parse(data[:n])
+			if err == nil && dev == nil {
+				t.Errorf("no device and no error parsing %d bytes", n)
+			}
+		}
+	}
+}
+
+func TestParseShortClassDescriptors(t *testing.T) {
+	// Class specific descriptors shorter than expected are ignored
+	dev, err := Parse(blob(t, `
+12 01 00 02 ef 02 01 40 41 23 54 80 00 01 01 02 03 01
+09 02 1f 00 01 01 00 80 fa
+09 04 00 00 01 02 02 00 00
+03 24 02
+03 24 06
+09 04 00 01 00 fe 01 02 04
+03 21 0b
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := dev.Configurations[0]
+	if cdc := config.Interface(0, 0).CDC; cdc == nil || cdc.HasACM || cdc.HasUnion {
+		t.Errorf("unexpected CDC functional descriptors %+v", cdc)
+	}
+	if dfu := config.Interface(0, 1).DFU; dfu != nil {
+		t.Errorf("unexpected DFU functional descriptor %+v", dfu)
+	}
+}
@@ -0,0 +1,149 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+// Package usbids looks up the names of USB vendors and products in a
+// usb.ids database, as maintained by the linux-usb project and shipped by
+// most Linux distributions.
+package usbids
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultPaths are the locations where the usb.ids database is usually
+// installed
+var DefaultPaths = []string{
+	"/usr/share/hwdata/usb.ids",
+	"/usr/share/misc/usb.ids",
+	"/usr/share/usb.ids",
+	"/var/lib/usbutils/usb.ids",
+	"/usr/local/share/hwdata/usb.ids",
+}
+
+// Database is an in-memory index of a usb.ids file
+type Database struct {
+	vendors map[uint16]*vendor
+}
+
+type vendor struct {
+	name     string
+	products map[uint16]string
+}
+
+// bundled is the copy of the usb.ids database embedded in the executable,
+// used when no database is installed in the system
+//
+//go:embed usb.ids
+var bundled []byte
+
+// LoadDefault loads the usb.ids database from the first of the
+// DefaultPaths that exists, or the bundled copy if none exists.
+func LoadDefault() (*Database, error) {
+	for _, path := range DefaultPaths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		return Load(path)
+	}
+	return Parse(bytes.NewReader(bundled))
+}
+
+// Load loads the usb.ids database from the given file
+func Load(path string) (*Database, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads a usb.ids database. Only the vendors and products list is
+// indexed, the other sections (device classes, HID usages...) are ignored.
+func Parse(r io.Reader) (*Database, error) {
+	db := &Database{vendors: map[uint16]*vendor{}}
+	var current *vendor
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if line[0] != '\t' {
+			// "vvvv  Vendor name", any other line starts a different section
+			current = nil
+			if id, name, ok := parseEntry(line); ok {
+				current = &vendor{name: name, products: map[uint16]string{}}
+				db.vendors[id] = current
+			}
+			continue
+		}
+		if current == nil || strings.HasPrefix(line, "\t\t") {
+			// Interfaces are not indexed
+			continue
+		}
+		// "\tpppp  Product name"
+		if id, name, ok := parseEntry(line[1:]); ok {
+			current.products[id] = name
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// parseEntry parses a line in the form "xxxx  Name"
+func parseEntry(line string) (uint16, string, bool) {
+	if len(line) < 7 || line[4:6] != "  " {
+		return 0, "", false
+	}
+	id, err := strconv.ParseUint(line[:4], 16, 16)
+	if err != nil {
+		return 0, "", false
+	}
+	return uint16(id), line[6:], true
+}
+
+// Lookup returns the names of the vendor and of the product with the given
+// VID and PID, given as hex strings with or without the "0x" prefix. Empty
+// strings are returned for unknown IDs.
+func (db *Database) Lookup(vid, pid string) (vendorName string, productName string) {
+	vendorID, ok := parseID(vid)
+	if !ok {
+		return "", ""
+	}
+	v, ok := db.vendors[vendorID]
+	if !ok {
+		return "", ""
+	}
+	if productID, ok := parseID(pid); ok {
+		productName = v.products[productID]
+	}
+	return v.name, productName
+}
+
+func parseID(id string) (uint16, bool) {
+	res, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(id), "0x"), 16, 16)
+	return uint16(res), err == nil
+}
@@ -0,0 +1,133 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+	"github.com/arduino/serial-discovery/filter"
+	"github.com/arduino/serial-discovery/sync"
+)
+
+// Exit codes of the "wait-for" command
+const (
+	waitForMet     = 0
+	waitForError   = 1
+	waitForTimeout = 2
+)
+
+// runWaitFor implements the "wait-for" command: it waits until a port
+// matching the given expression appears, or until all the matching ports
+// are gone, and prints the port in the given format
+func runWaitFor(match string, gone bool, timeout time.Duration, format string, portFilter *filter.Filter) int {
+	matcher, err := filter.Parse(match)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid argument: %s\n", err)
+		return waitForError
+	}
+	printer, err := newPortPrinter(format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid argument: %s\n", err)
+		return waitForError
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	done := make(chan *discovery.Port, 1)
+	notify := func(port *discovery.Port) {
+		select {
+		case done <- port:
+		default:
+		}
+	}
+	errs := make(chan string, 1)
+	errorCB := func(msg string) {
+		select {
+		case errs <- msg:
+		default:
+		}
+	}
+	// The events are serialized by the filter stream
+	present := map[string]bool{}
+	eventCB := func(event string, port *discovery.Port) {
+		id := port.Address + "|" + port.Protocol
+		matches := event != "remove" && matcher.Match(port)
+		switch {
+		case !gone && matches:
+			notify(port)
+		case gone && matches:
+			present[id] = true
+		case gone && present[id]:
+			delete(present, id)
+			if len(present) == 0 {
+				notify(port)
+			}
+		}
+	}
+	if err := sync.Start(ctx, filter.NewStream(portFilter, eventCB).Event, errorCB); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return waitForError
+	}
+
+	if gone {
+		// Nothing to wait for if no port is matching right now
+		ports, err := sync.List(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			return waitForError
+		}
+		matching := false
+		for _, port := range ports {
+			matching = matching || (portFilter.Match(port) && matcher.Match(port))
+		}
+		if !matching {
+			return waitForMet
+		}
+	}
+
+	select {
+	case port := <-done:
+		if err := printer(os.Stdout, []*discovery.Port{port}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			return waitForError
+		}
+		return waitForMet
+	case msg := <-errs:
+		fmt.Fprintf(os.Stderr, "Error: %s\n", msg)
+		return waitForError
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			fmt.Fprintf(os.Stderr, "Timeout after %s\n", timeout)
+			return waitForTimeout
+		}
+		return waitForError
+	}
+}
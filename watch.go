@@ -0,0 +1,201 @@
+//
+// This file is part of serial-discovery.
+//
+// Copyright 2026 ARDUINO SA (http://www.arduino.cc/)
+//
+// This software is released under the GNU General Public License version 3,
+// which covers the main part of arduino-cli.
+// The terms of this license can be found at:
+// https://www.gnu.org/licenses/gpl-3.0.en.html
+//
+// You can be released from the requirements of the above licenses by purchasing
+// a commercial license. Buying such a license is mandatory if you want to modify or
+// otherwise use the software for commercial activities involving the Arduino
+// software without disclosing the source code of your own applications. To purchase
+// a commercial license, send an email to license@arduino.cc.
+//
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	discovery "github.com/arduino/pluggable-discovery-protocol-handler/v2"
+	"github.com/arduino/serial-discovery/filter"
+	"github.com/arduino/serial-discovery/sync"
+)
+
+// ANSI escape sequences used to color the watch output
+const (
+	colorReset  = "\x1b[0m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorDim    = "\x1b[2m"
+)
+
+// watcher prints the port events as a timestamped log
+type watcher struct {
+	out     io.Writer
+	columns []string
+	color   bool
+	ports   map[string]*discovery.Port
+}
+
+// runWatch implements the "watch" command: the port events are printed
+// until the process is interrupted
+func runWatch(color string, columns []string, portFilter *filter.Filter) int {
+	w := &watcher{
+		out:     os.Stdout,
+		columns: columns,
+		ports:   map[string]*discovery.Port{},
+	}
+	switch color {
+	case "always":
+		w.color = true
+	case "never":
+		w.color = false
+	case "auto":
+		w.color = isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == ""
+	default:
+		fmt.Fprintf(os.Stderr, "invalid argument: --color must be auto, always or never\n")
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errs := make(chan string, 1)
+	stream := filter.NewStream(portFilter, w.event)
+	errorCB := func(msg string) {
+		select {
+		case errs <- msg:
+		default:
+		}
+	}
+	if err := sync.Start(ctx, stream.Event, errorCB); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
+	select {
+	case <-ctx.Done():
+		return 0
+	case msg := <-errs:
+		fmt.Fprintf(os.Stderr, "Error: %s\n", msg)
+		return 1
+	}
+}
+
+// event prints a port event, the change events are followed by the list of
+// the attributes that have changed
+func (w *watcher) event(event string, port *discovery.Port) {
+	id := port.Address + "|" + port.Protocol
+	var symbol, color string
+	switch event {
+	case "add":
+		symbol, color = "+", colorGreen
+	case "remove":
+		symbol, color = "-", colorRed
+	case "change":
+		symbol, color = "~", colorYellow
+	default:
+		symbol, color = "?", ""
+	}
+
+	var line strings.Builder
+	line.WriteString(w.paint(colorDim, time.Now().Format("15:04:05.000")))
+	line.WriteString(" ")
+	line.WriteString(w.paint(color, fmt.Sprintf("%s %-6s", symbol, event)))
+	for _, column := range w.columns {
+		if value := portColumn(port, column); value != "" {
+			line.WriteString("  ")
+			if isPortField(column) {
+				line.WriteString(value)
+			} else {
+				line.WriteString(column + "=" + value)
+			}
+		}
+	}
+	if old, ok := w.ports[id]; ok && event == "change" {
+		for _, change := range portChanges(old, port) {
+			line.WriteString("\n" + strings.Repeat(" ", 23))
+			line.WriteString(w.paint(colorYellow, change))
+		}
+	}
+	fmt.Fprintln(w.out, line.String())
+
+	if event == "remove" {
+		delete(w.ports, id)
+	} else {
+		w.ports[id] = port
+	}
+}
+
+func (w *watcher) paint(color, text string) string {
+	if !w.color || color == "" {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// isPortField returns true if the column is a field of the port rather
+// than one of its properties
+func isPortField(column string) bool {
+	switch column {
+	case "address", "label", "protocol", "protocolLabel", "hardwareId":
+		return true
+	}
+	return false
+}
+
+// portColumn returns the value of a field or a property of the port
+func portColumn(port *discovery.Port, column string) string {
+	switch column {
+	case "address":
+		return port.Address
+	case "label":
+		return port.AddressLabel
+	case "protocol":
+		return port.Protocol
+	case "protocolLabel":
+		return port.ProtocolLabel
+	case "hardwareId":
+		return port.HardwareID
+	}
+	return port.Properties.Get(column)
+}
+
+// portChanges describes the differences between two versions of a port
+func portChanges(old, new *discovery.Port) []string {
+	res := []string{}
+	for _, field := range []string{"label", "protocolLabel", "hardwareId"} {
+		if a, b := portColumn(old, field), portColumn(new, field); a != b {
+			res = append(res, fmt.Sprintf("%s: %q -> %q", field, a, b))
+		}
+	}
+	for _, key := range old.Properties.Keys() {
+		if b, ok := new.Properties.GetOk(key); !ok {
+			res = append(res, fmt.Sprintf("%s: %q removed", key, old.Properties.Get(key)))
+		} else if a := old.Properties.Get(key); a != b {
+			res = append(res, fmt.Sprintf("%s: %q -> %q", key, a, b))
+		}
+	}
+	for _, key := range new.Properties.Keys() {
+		if !old.Properties.ContainsKey(key) {
+			res = append(res, fmt.Sprintf("%s: %q added", key, new.Properties.Get(key)))
+		}
+	}
+	return res
+}
+
+// isTerminal returns true if the file is a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}